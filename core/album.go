@@ -0,0 +1,154 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+type albumEndpointJSON struct {
+	Album albumJSON
+}
+
+// Top level response for a single album.
+type albumResponseJSON struct {
+	Response albumEndpointJSON
+}
+
+// Fields requested when showing a single album.
+const albumDetailFilter = "AlbumKey,NodeID,Name,Uri,WebUri,UrlPath,Description,ImageCount,Privacy,LastUpdated"
+
+// parseAlbumFields converts field=value arguments into the body of an album
+// PATCH request.  true and false are sent as booleans and Privacy is checked.
+func parseAlbumFields(args []string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		eq := strings.Index(arg, "=")
+		if eq < 1 {
+			return nil, fmt.Errorf("expected field=value, got %s", arg)
+		}
+
+		field, value := arg[:eq], arg[eq+1:]
+		switch {
+		case strings.EqualFold(field, "Privacy"):
+			privacy, err := normalizePrivacy(value)
+			if err != nil {
+				return nil, err
+			}
+			fields["Privacy"] = privacy
+		case value == "true":
+			fields[field] = true
+		case value == "false":
+			fields[field] = false
+		default:
+			fields[field] = value
+		}
+	}
+
+	return fields, nil
+}
+
+// getAlbum retrieves a single album.
+func getAlbum(client *http.Client, userToken *oauth.Credentials, albumKey string) (albumJSON, error) {
+	return getAlbumFields(client, userToken, albumKey, albumDetailFilter)
+}
+
+// getAlbumFields retrieves the fields named by filter of a single album.
+func getAlbumFields(client *http.Client, userToken *oauth.Credentials,
+	albumKey string, filter string) (albumJSON, error) {
+
+	var queryParams = url.Values{
+		"_filter":    {filter},
+		"_filteruri": {""},
+	}
+
+	var respJSON albumResponseJSON
+	err := apiGet(client, userToken, apiAlbum+"/"+albumKey, queryParams, &respJSON)
+	return respJSON.Response.Album, err
+}
+
+// setAlbum changes the given fields of an album.
+func setAlbum(albumKey string, args []string) {
+	fields, err := parseAlbumFields(args)
+	if err != nil {
+		log.Println("Error: " + err.Error())
+		return
+	}
+
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	var respJSON albumResponseJSON
+	err = apiSend(&client, userToken, "PATCH", apiAlbum+"/"+albumKey, fields, &respJSON)
+	if err != nil {
+		log.Println("Error updating album: " + err.Error())
+		return
+	}
+
+	album := respJSON.Response.Album
+	fmt.Println("Updated " + album.Name + " :: " + album.AlbumKey)
+
+	db := openDB()
+	defer db.Close()
+
+	if isAlbumCached(db, albumKey) {
+		writeAlbumData(db, []albumJSON{album})
+	}
+}
+
+// deleteAlbum deletes an album after asking for confirmation and removes it
+// from the DB.
+func deleteAlbum(albumKey string, skipConfirm bool) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	album, err := getAlbum(&client, userToken, albumKey)
+	if err != nil {
+		log.Println("Error getting album: " + err.Error())
+		return
+	}
+
+	question := fmt.Sprintf("Delete album %s with %d images?", album.Name, album.ImageCount)
+	if !skipConfirm && !confirm(question) {
+		return
+	}
+
+	err = apiSend(&client, userToken, "DELETE", apiAlbum+"/"+albumKey, nil, nil)
+	if err != nil {
+		log.Println("Error deleting album: " + err.Error())
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	removeAlbumImages(db, albumKey)
+	removeAlbumData(db, albumKey)
+	fmt.Println("Deleted " + album.Name + " :: " + albumKey)
+}
@@ -0,0 +1,47 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAlbumFields(t *testing.T) {
+	args := []string{"Name=Smith = Jones", "privacy=private", "AllowDownloads=false", "Description="}
+	expected := map[string]interface{}{
+		"Name":           "Smith = Jones",
+		"Privacy":        privacyPrivate,
+		"AllowDownloads": false,
+		"Description":    "",
+	}
+
+	actual, err := parseAlbumFields(args)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestParseAlbumFieldsErrors(t *testing.T) {
+	for _, arg := range []string{"Name", "=Smith", "Privacy=secret"} {
+		if _, err := parseAlbumFields([]string{arg}); err == nil {
+			t.Errorf("Expected error for %s", arg)
+		}
+	}
+}
@@ -15,7 +15,7 @@
 package main
 
 import (
-	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,8 +23,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"path"
 	"sort"
-	"sync"
 	"time"
 
 	"github.com/gomodule/oauth1/oauth"
@@ -52,10 +53,28 @@ type pagesJSON struct {
 }
 
 type albumJSON struct {
-	AlbumKey string
-	Name     string
+	AlbumKey          string
+	NodeID            string
+	Name              string
+	URI               string
+	WebURI            string
+	URLPath           string
+	Description       string
+	ImageCount        int
+	Privacy           string
+	LastUpdated       string
+	ImagesLastUpdated string
+
+	// Not part of SmugMug's album.  Only set for albums read from the DB.
+	FolderPath string `json:"-"`
 }
 
+// Fields requested when listing albums.
+const albumListFilter = "AlbumKey,Name"
+
+// Fields saved in the album table.
+const albumCacheFilter = "AlbumKey,NodeID,Name,Uri,UrlPath,Description,ImageCount,Privacy,LastUpdated"
+
 // Sort album array by Name for printing.
 type byName []albumJSON
 
@@ -89,24 +108,42 @@ type imageJSON struct {
 	FileName    string
 }
 
-type imagesPagesJSON struct {
-	Total          int
-	Start          int
-	Count          int
-	RequestedCount int
+// albumImageJSON holds the image fields used by commands that need more than
+// the MD5 hash.  Only the fields named in the request's filter are filled in.
+type albumImageJSON struct {
+	ImageKey         string
+	FileName         string
+	ArchivedMD5      string
+	ArchivedSize     int64
+	ArchivedURI      string
+	URI              string
+	DateTimeUploaded string
+	Caption          string
+	KeywordArray     []string
+	OriginalWidth    int
+	OriginalHeight   int
+	Hidden           bool
+	WebURI           string
+	Uris             imageUrisJSON
+}
+
+type imageUrisJSON struct {
+	ImageAlbum uriValue
 }
 
 type imagesJSON struct {
-	AlbumImage []imageJSON
+	AlbumImage []albumImageJSON
 	Pages      pagesJSON
 }
 
-// Top level response from the AlbumImages URI.  We just want the MD5 for all
-// the images so we can avoid uploading duplicates.
+// Top level response from the AlbumImages URI.
 type imagesResponseJSON struct {
 	Response imagesJSON
 }
 
+// Fields requested when refreshing an album's hashes.
+const imageHashFilter = "ImageKey,ArchivedMD5,FileName"
+
 // getUser retrieves the URI that serves the current user.
 func getUser(userToken *oauth.Credentials) (string, error) {
 	var queryParams = url.Values{
@@ -128,7 +165,7 @@ func getUser(userToken *oauth.Credentials) (string, error) {
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		fmt.Println("getUser response: " + resp.Status)
+		log.Println("getUser response: " + resp.Status)
 	}
 
 	var respJSON responseJSON
@@ -139,7 +176,7 @@ func getUser(userToken *oauth.Credentials) (string, error) {
 	}
 
 	if respJSON.Response.User.URI == "" {
-		fmt.Println("No Uri object found in getUser response.")
+		log.Println("No Uri object found in getUser response.")
 		return "", errors.New("no Uri object found in getUser response")
 	}
 
@@ -147,11 +184,14 @@ func getUser(userToken *oauth.Credentials) (string, error) {
 }
 
 // printAlbums prints all the albums after sorting alphabetically.
-func printAlbums(albums []albumJSON) {
+func printAlbums(format listFormat, albums []albumJSON) {
 	sort.Sort(byName(albums))
-	for _, album := range albums {
-		fmt.Println(album.Name + " :: " + album.AlbumKey)
+	lw := format.newWriter(os.Stdout, albumRecord{})
+	if err := printSearchResults(lw, albums); err != nil {
+		log.Println("Error printing albums: " + err.Error())
+		return
 	}
+	lw.close()
 }
 
 // aggregateTerms combines search terms into a single string with each search
@@ -168,8 +208,43 @@ func aggregateTerms(terms []string) string {
 	return combinedTerms
 }
 
-// search is the entry point to album search.
-func search(terms []string) {
+// search is the entry point to album search.  Albums in the DB are ranked by
+// how closely they match the terms, unless remote is true, in which case
+// SmugMug's search is used instead.  The DB is filled first if it has no
+// albums.  At most limit albums are listed, unless limit is 0.  Structured
+// formats never ask before listing more results.
+func search(terms []string, remote bool, limit int, all bool, format listFormat) {
+	lw := format.newWriter(os.Stdout, albumRecord{})
+	defer lw.close()
+
+	if !remote {
+		db := openDB()
+		defer db.Close()
+
+		cached := getAlbumData(db)
+		if len(cached) == 0 {
+			var err error
+			cached, err = refreshAlbums(db)
+			if err != nil {
+				log.Println("Error getting albums: " + err.Error())
+				return
+			}
+		}
+
+		ranked := rankAlbums(cached, terms)
+		if limit > 0 && len(ranked) > limit {
+			ranked = ranked[:limit]
+		}
+		results := make([]albumJSON, 0, len(ranked))
+		for _, r := range ranked {
+			results = append(results, r.Album)
+		}
+		if err := printSearchResults(lw, results); err != nil {
+			log.Println("Error printing albums: " + err.Error())
+		}
+		return
+	}
+
 	userToken, err := loadUserToken()
 	if err != nil {
 		log.Println("Error reading OAuth token: " + err.Error())
@@ -184,14 +259,30 @@ func search(terms []string) {
 	combinedTerms := aggregateTerms(terms)
 	var client = http.Client{}
 
-	searchRequest(&client, userToken, userURI, combinedTerms, 1)
+	all = all || format.structured()
+	shown, err := searchPages(limit, all, func(start int, count int) (int, pagesJSON, error) {
+		page, err := searchRequest(&client, userToken, userURI, combinedTerms, start, count)
+		if err != nil {
+			return 0, page.Pages, err
+		}
+		return len(page.Album), page.Pages, printSearchResults(lw, page.Album)
+	})
+	if err != nil {
+		log.Println("Error searching albums: " + err.Error())
+		return
+	}
+
+	if shown == 0 && !format.structured() {
+		fmt.Println("No search results found.")
+	}
 }
 
-// searchRequest sends the search request to SmugMug and asks for the entries beginning at start.
-func searchRequest(client *http.Client, userToken *oauth.Credentials, userURI string, query string, start int) {
+// searchRequest sends the search request to SmugMug and asks for up to count
+// entries beginning at start.
+func searchRequest(client *http.Client, userToken *oauth.Credentials,
+	userURI string, query string, start int, count int) (searchJSON, error) {
+
 	var queryParams = url.Values{
-		"_accept":       {"application/json"},
-		"_verbosity":    {"1"},
 		"_filter":       {"Album,Name,AlbumKey"},
 		"_filteruri":    {""},
 		"Scope":         {userURI},
@@ -199,370 +290,338 @@ func searchRequest(client *http.Client, userToken *oauth.Credentials, userURI st
 		"SortMethod":    {"Rank"},
 		"Text":          {query},
 		"start":         {fmt.Sprintf("%d", start)},
-		"count":         {"15"},
+		"count":         {fmt.Sprintf("%d", count)},
 	}
 
-	resp, err := oauthClient.Get(client, userToken, searchAlbums, queryParams)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+	var respJSON searchResponseJSON
+	if err := apiGet(client, userToken, searchAlbums, queryParams, &respJSON); err != nil {
+		return searchJSON{}, fmt.Errorf("album search starting at %d: %v", start, err)
 	}
 
-	bytes, err := func() ([]byte, error) {
-		defer resp.Body.Close()
-		b, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return b, nil
-	}()
+	return respJSON.Response, nil
+}
 
-	if err != nil {
-		log.Println("Reading search results: " + err.Error())
-		return
+// printSearchResults outputs the albums with lw.  The text format shows the
+// album names and keys.
+func printSearchResults(lw *listWriter, results []albumJSON) error {
+	records := make([]albumRecord, 0, len(results))
+	for _, album := range results {
+		records = append(records, toAlbumRecord(album))
 	}
 
-	var respJSON searchResponseJSON
-	err = json.Unmarshal(bytes, &respJSON)
-	if err != nil {
-		log.Println("Decoding album search endpoint JSON: " + err.Error())
-		return
-	}
+	return lw.write(records, func(i int) string {
+		return records[i].Name + " :: " + records[i].AlbumKey
+	})
+}
 
-	if len(respJSON.Response.Album) < 1 {
-		fmt.Println("No search results found.")
-		return
-	}
+// albums lists all the albums (and their keys) that belong to the user.  The
+// albums are read from the DB unless refresh is true or the DB has no albums.
+func albums(refresh bool, format listFormat) {
+	db := openDB()
+	defer db.Close()
 
-	printSearchResults(respJSON.Response.Album)
+	if !refresh {
+		cached := getAlbumData(db)
+		if len(cached) > 0 {
+			printAlbums(format, cached)
+			return
+		}
+	}
 
-	pages := &respJSON.Response.Pages
-	if pages.Count+pages.Start < pages.Total {
-		fmt.Println("Press Enter for more results or Ctrl-C to quit.")
-		var foo string
-		fmt.Scanln(&foo)
-		searchRequest(client, userToken, userURI, query, pages.Count+pages.Start)
+	startT := time.Now()
+	albums, err := refreshAlbums(db)
+	if err != nil {
+		log.Println("Error getting albums: " + err.Error())
+		return
 	}
-}
 
-// printSearchResults outputs the album names and keys to stdout.
-func printSearchResults(results []albumJSON) {
-	for _, album := range results {
-		fmt.Println(album.Name + " :: " + album.AlbumKey)
+	printAlbums(format, albums)
+	if !format.structured() {
+		totalT := time.Since(startT)
+		fmt.Println("\nElapsed time: " + totalT.String())
 	}
 }
 
-// albums lists all the albums (and their keys) that belong to the user.
-func albums() {
+// refreshAlbums replaces the albums in the DB with the user's current albums
+// on SmugMug.  The albums are also returned.
+func refreshAlbums(db *sql.DB) ([]albumJSON, error) {
 	userToken, err := loadUserToken()
 	if err != nil {
-		log.Println("Error reading OAuth token: " + err.Error())
-		return
+		return nil, err
 	}
 
 	userURI, err := getUser(userToken)
 	if err != nil {
-		return
+		return nil, err
 	}
 
-	startT := time.Now()
-	albumsURI := apiRoot + userURI + apiMultiAlbums
 	var client = http.Client{}
-	epChan := make(chan endpointJSON, 10)
-	fmt.Println("Requesting number of albums.")
-	getAlbumPage(&client, userToken, albumsURI, 1, 1, epChan)
-	ep := <-epChan
-
-	if ep.Pages.Count >= ep.Pages.Total {
-		printAlbums(ep.Album)
-		return
-	}
-
-	waitGrp := sync.WaitGroup{}
-	start := ep.Pages.Count + 1
-
-	for start < ep.Pages.Total {
-		fmt.Printf("Requesting %d albums starting at %d.\n", albumPageSize, start)
-		waitGrp.Add(1)
-		go func(startInd int) {
-			defer waitGrp.Done()
-			getAlbumPage(&client, userToken, albumsURI, startInd, albumPageSize, epChan)
-		}(start)
-		start += albumPageSize
+	albums, err := fetchAlbums(&client, userToken, userURI, albumCacheFilter)
+	if err != nil {
+		return nil, err
 	}
 
-	albums := make([]albumJSON, 1, ep.Pages.Total)
-	copy(albums, ep.Album)
+	replaceAlbumData(db, albums)
+	return albums, nil
+}
 
-	albumsReqDoneChan := make(chan bool)
-	resultsPrintedChan := make(chan bool)
-	go collectAlbumResults(albums, albumsReqDoneChan, epChan,
-		resultsPrintedChan)
+// fetchAlbums retrieves the fields named by filter for all the user's
+// albums.  The first page reports the total number of albums, then the
+// remaining pages are requested in parallel.
+func fetchAlbums(client *http.Client, userToken *oauth.Credentials,
+	userURI string, filter string) ([]albumJSON, error) {
 
-	waitGrp.Wait()
+	albumsURI := apiRoot + userURI + apiMultiAlbums
+	fmt.Fprintln(os.Stderr, "Requesting number of albums.")
+	first, err := getAlbumPage(client, userToken, albumsURI, filter, 1, albumPageSize)
+	if err != nil {
+		return nil, err
+	}
 
-	// Tell collectAlbumResults() that all album requests finished.
-	albumsReqDoneChan <- true
+	if first.Pages.Count >= first.Pages.Total {
+		return first.Album, nil
+	}
 
-	// Wait for albums to be displayed.
-	<-resultsPrintedChan
-	totalT := time.Since(startT)
-	fmt.Println("\nElapsed time: " + totalT.String())
-}
+	starts := remainingPageStarts(first.Pages, albumPageSize)
+	pages := make([][]albumJSON, len(starts))
+	err = getPages(starts, func(i int, start int) error {
+		fmt.Fprintf(os.Stderr, "Requesting %d albums starting at %d.\n", albumPageSize, start)
+		page, err := getAlbumPage(client, userToken, albumsURI, filter, start, albumPageSize)
+		pages[i] = page.Album
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// collectAlbumResults receives albums over epChan from getAlbumPage().  It
-// continues to listen to epChan until receiving true from albumsReqDoneChan.
-// Finally, it outputs the albums to stdout and indicates completion by
-// sending true over resultsPrintedChan.
-func collectAlbumResults(
-	albums []albumJSON,
-	albumsReqDoneChan chan bool,
-	epChan chan endpointJSON,
-	resultsPrintedChan chan bool) {
-
-	fmt.Println(albums)
-	done := false
-	for !done || len(epChan) > 0 {
-		select {
-		case epAlbs := <-epChan:
-			albums = append(albums, epAlbs.Album...)
-		case done = <-albumsReqDoneChan:
-		}
+	albums := make([]albumJSON, 0, first.Pages.Total)
+	albums = append(albums, first.Album...)
+	for _, page := range pages {
+		albums = append(albums, page...)
 	}
 
-	printAlbums(albums)
-	resultsPrintedChan <- true
+	return albums, nil
 }
 
-// getAlbumPage gets up to count albums starting at index start.  It returns
-// the album and page data over epChan, so it may be invoked as a goroutine.
+// getAlbumPage gets up to count albums starting at index start.
 func getAlbumPage(
 	client *http.Client, userToken *oauth.Credentials,
-	albumsURI string, start int, count int,
-	epChan chan endpointJSON) {
+	albumsURI string, filter string, start int, count int) (endpointJSON, error) {
 
 	var queryParams = url.Values{
-		"_accept":    {"application/json"},
-		"_verbosity": {"1"},
-		"_filter":    {"AlbumKey,Name"},
+		"_filter":    {filter},
 		"_filteruri": {""},
 		"start":      {fmt.Sprintf("%d", start)},
 		"count":      {fmt.Sprintf("%d", count)},
 	}
 
-	resp, err := oauthClient.Get(client, userToken, albumsURI, queryParams)
-	if err != nil {
-		return
+	var respJSON responseJSON
+	if err := apiGet(client, userToken, albumsURI, queryParams, &respJSON); err != nil {
+		return endpointJSON{}, fmt.Errorf("albums starting at %d: %v", start, err)
 	}
 
-	bytes, err := func() ([]byte, error) {
-		defer resp.Body.Close()
-		b, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return b, nil
-	}()
+	return respJSON.Response, nil
+}
 
-	if err != nil {
-		log.Println("Reading albums: " + err.Error())
-		return
+// albumSettings are the optional settings for a new album.
+type albumSettings struct {
+	URLName       string
+	Privacy       string
+	Description   string
+	SortMethod    string
+	SortDirection string
+	Password      string
+}
+
+// createAlbum creates an album named name in the folder served by folderURI.
+func createAlbum(client *http.Client, credentials *oauth.Credentials,
+	folderURI string, name string, settings albumSettings) (albumJSON, error) {
+
+	var body = map[string]string{
+		"Name":    name,
+		"UrlName": settings.URLName,
+	}
+	if body["UrlName"] == "" {
+		body["UrlName"] = urlName(name)
+	}
+	if settings.Privacy != "" {
+		body["Privacy"] = settings.Privacy
+	}
+	if settings.Description != "" {
+		body["Description"] = settings.Description
+	}
+	if settings.SortMethod != "" {
+		body["SortMethod"] = settings.SortMethod
+	}
+	if settings.SortDirection != "" {
+		body["SortDirection"] = settings.SortDirection
+	}
+	if settings.Password != "" {
+		body["SecurityType"] = securityPassword
+		body["Password"] = settings.Password
 	}
 
-	var respJSON responseJSON
-	err = json.Unmarshal(bytes, &respJSON)
+	var respJSON folderResponseJSON
+	err := apiSend(client, credentials, "POST", folderURI+apiFolderAlbums, body, &respJSON)
 	if err != nil {
-		log.Println("Decoding album endpoint JSON: " + err.Error())
-		return
+		return albumJSON{}, err
 	}
 
-	if len(respJSON.Response.Album) < 1 {
-		fmt.Println("No albums found.")
-		return
+	if respJSON.Response.Album.AlbumKey == "" {
+		return albumJSON{}, errors.New("no album found in create album response")
 	}
 
-	epChan <- respJSON.Response
+	return respJSON.Response.Album, nil
 }
 
-// createAlbum was test code for exercising the SmugMug API.  It works, but is
-// hard coded for a particular album in a particular location.
-func createAlbum(client *http.Client, credentials *oauth.Credentials) {
-	createURI := apiRoot + "/api/v2/node/R3gfM!children"
-
-	var body = map[string]string{
-		"Type":    "Album",
-		"Name":    "Test Post Create",
-		"UrlName": "Test-Post-Create",
-		"Privacy": "Public",
-	}
-
-	rawJSON, err := json.Marshal(body)
+// mkalbum creates an album in the folder at folderPath, creating any missing
+// folders along the way, and prints the new album's key.
+func mkalbum(folderPath string, name string, settings albumSettings, cache bool) {
+	userToken, err := loadUserToken()
 	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
 		return
 	}
-	fmt.Println(string(rawJSON))
 
-	req, err := http.NewRequest("POST", createURI, bytes.NewReader(rawJSON))
+	userURI, err := getUser(userToken)
 	if err != nil {
 		return
 	}
 
-	req.Header["Content-Type"] = []string{"application/json"}
-	req.Header["Content-Length"] = []string{fmt.Sprintf("%d", len(rawJSON))}
-	req.Header["Accept"] = []string{"application/json"}
-
-	if err := oauthClient.SetAuthorizationHeader(
-		req.Header, credentials, "POST", req.URL, url.Values{}); err != nil {
-		// req.Header, credentials, "POST", req.URL, headers); err != nil {
+	var client = http.Client{}
+	nickname := path.Base(userURI)
+	folder, err := ensureFolder(&client, userToken, nickname, folderPath, true, settings.Privacy)
+	if err != nil {
+		log.Println("Error finding folder: " + err.Error())
 		return
 	}
 
-	fmt.Println(req)
-
-	var resp *http.Response
-	resp, err = client.Do(req)
+	album, err := createAlbum(&client, userToken, folderPathURI(nickname, folder.URLPath), name, settings)
 	if err != nil {
+		log.Println("Error creating album: " + err.Error())
 		return
 	}
 
-	defer resp.Body.Close()
+	if album.WebURI != "" {
+		fmt.Println("Created " + album.WebURI)
+	}
 
-	bytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return
+	if cache {
+		db := openDB()
+		defer db.Close()
+
+		writeAlbumData(db, []albumJSON{album})
 	}
 
-	fmt.Println(resp.Status)
-	fmt.Println(string(bytes))
+	fmt.Println(album.AlbumKey)
 }
 
-// albumImages retrieves the MD5 hash codes of the images in an album.
-func albumImages(albumKey string) {
-
+// albumImages brings the MD5 hash codes of the images in an album up to date
+// and prints the images.  If full is true, every image is fetched, instead of
+// only new images.
+func albumImages(albumKey string, full bool, format listFormat) {
 	userToken, err := loadUserToken()
 	if err != nil {
 		log.Println("Error reading OAuth token: " + err.Error())
 		return
 	}
 
-	uri := apiAlbum + "/" + albumKey + "!images"
 	var client = http.Client{}
-	imgsChan := make(chan imagesJSON, 10)
-	getAlbumImagesPage(&client, userToken, uri, 1, 1, imgsChan)
-	img := <-imgsChan
-
-	fmt.Printf("Got %d images out of %d.\n", img.Pages.Count, img.Pages.Total)
-	if img.Pages.Count >= img.Pages.Total {
-		db := openDB()
-		defer db.Close()
+	db := openDB()
+	defer db.Close()
 
-		// First remove any image data for the given album because we are getting
-		// new truth data.
-		removeAlbumImages(db, albumKey)
-		writeImageData(db, albumKey, img.AlbumImage)
+	images, err := updateAlbumImages(&client, userToken, db, albumKey, full)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
 		return
 	}
 
-	waitGrp := sync.WaitGroup{}
-	start := img.Pages.Count + 1
-
-	for start < img.Pages.Total {
-		fmt.Printf("Requesting %d images starting at %d.\n", albumPageSize, start)
-		waitGrp.Add(1)
-		go func(startInd int) {
-			defer waitGrp.Done()
-			getAlbumImagesPage(&client, userToken, uri, startInd, albumPageSize, imgsChan)
-		}(start)
-		start += albumPageSize
+	if err := printImages(format, albumKey, images); err != nil {
+		log.Println("Error printing images: " + err.Error())
 	}
+}
 
-	imgData := make([]imageJSON, img.Pages.Count, img.Pages.Total)
-	copy(imgData, img.AlbumImage)
-
-	imgsReqDoneChan := make(chan bool)
-	gotAllImgsChan := make(chan bool)
-	go collectImageResults(imgData, albumKey, imgsReqDoneChan, imgsChan,
-		gotAllImgsChan)
+// printImages outputs the images of an album to stdout.  The text format
+// shows the filenames and image keys.
+func printImages(format listFormat, albumKey string, images []albumImageJSON) error {
+	records := make([]imageRecord, 0, len(images))
+	for _, img := range images {
+		records = append(records, toImageRecord(albumKey, img))
+	}
 
-	waitGrp.Wait()
+	return format.writeList(os.Stdout, records, func(i int) string {
+		return records[i].FileName + " :: " + records[i].ImageKey
+	})
+}
 
-	// Tell collectImageResults() that all album image requests finished.
-	imgsReqDoneChan <- true
+// fetchAlbumImages retrieves the fields named by filter for every image in an
+// album.  The first page reports the total number of images, then the
+// remaining pages are requested in parallel.
+func fetchAlbumImages(client *http.Client, userToken *oauth.Credentials,
+	albumKey string, filter string) ([]albumImageJSON, error) {
 
-	// Wait for image data collection to complete.
-	<-gotAllImgsChan
-}
+	uri := apiAlbum + "/" + albumKey + "!images"
+	first, err := getAlbumImagesPage(client, userToken, uri, filter, 1, albumPageSize)
+	if err != nil {
+		return nil, err
+	}
 
-// collectImageResults receives albums over imgsChan from getAlbumImagesPage().
-// It continues to listen to imgsChan until receiving true from imgsReqDoneChan.
-// Finally, it indicates completion by sending true over gotAllImgsChan.
-func collectImageResults(imgData []imageJSON, albumKey string,
-	imgsReqDoneChan chan bool, imgsChan chan imagesJSON, gotAllImgsChan chan bool) {
-
-	done := false
-	for !done || len(imgsChan) > 0 {
-		select {
-		case newImages := <-imgsChan:
-			imgData = append(imgData, newImages.AlbumImage...)
-		case done = <-imgsReqDoneChan:
-		}
+	fmt.Fprintf(os.Stderr, "Got %d images out of %d.\n", first.Pages.Count, first.Pages.Total)
+	if first.Pages.Count >= first.Pages.Total {
+		return first.AlbumImage, nil
 	}
 
-	fmt.Println("Got", len(imgData), "images")
+	starts := remainingPageStarts(first.Pages, albumPageSize)
+	pages := make([][]albumImageJSON, len(starts))
+	err = getPages(starts, func(i int, start int) error {
+		fmt.Fprintf(os.Stderr, "Requesting %d images starting at %d.\n", albumPageSize, start)
+		page, err := getAlbumImagesPage(client, userToken, uri, filter, start, albumPageSize)
+		pages[i] = page.AlbumImage
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	db := openDB()
-	defer db.Close()
+	images := make([]albumImageJSON, 0, first.Pages.Total)
+	images = append(images, first.AlbumImage...)
+	for _, page := range pages {
+		images = append(images, page...)
+	}
 
-	// First remove any image data for the given album because we are getting
-	// new truth data.
-	removeAlbumImages(db, albumKey)
+	return images, nil
+}
 
-	// Save the data received from SmugMug.
-	writeImageData(db, albumKey, imgData)
+// getAlbumImagesPage gets up to count images starting at index start.
+func getAlbumImagesPage(
+	client *http.Client, userToken *oauth.Credentials,
+	uri string, filter string, start int, count int) (imagesJSON, error) {
 
-	gotAllImgsChan <- true
+	return getSortedAlbumImagesPage(client, userToken, uri, filter, "", start, count)
 }
 
-func getAlbumImagesPage(
+// getSortedAlbumImagesPage gets up to count images starting at index start.
+// If sortMethod is set, such as DateUploaded, the images are sorted by it
+// newest first, instead of in album order.
+func getSortedAlbumImagesPage(
 	client *http.Client, userToken *oauth.Credentials,
-	uri string, start int, count int,
-	imgsChan chan imagesJSON) {
+	uri string, filter string, sortMethod string, start int, count int) (imagesJSON, error) {
 
 	var queryParams = url.Values{
-		"_accept":    {"application/json"},
-		"_verbosity": {"1"},
-		"_filter":    {"ArchivedMD5,FileName"},
+		"_filter":    {filter},
 		"_filteruri": {""},
 		"start":      {fmt.Sprintf("%d", start)},
-		"count":      {"count"},
-	}
-
-	resp, err := oauthClient.Get(client, userToken, uri, queryParams)
-	if err != nil {
-		fmt.Println(err.Error())
-		return
+		"count":      {fmt.Sprintf("%d", count)},
 	}
-
-	bytes, err := func() ([]byte, error) {
-		defer resp.Body.Close()
-		b, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		return b, nil
-	}()
-
-	if err != nil {
-		log.Println("Reading album images: " + err.Error())
-		return
+	if sortMethod != "" {
+		queryParams.Set("SortMethod", sortMethod)
+		queryParams.Set("SortDirection", "Descending")
 	}
 
 	var respJSON imagesResponseJSON
-	err = json.Unmarshal(bytes, &respJSON)
-	if err != nil {
-		log.Println("Decoding album images endpoint JSON: " + err.Error())
-		return
+	if err := apiGet(client, userToken, uri, queryParams, &respJSON); err != nil {
+		return imagesJSON{}, fmt.Errorf("album images starting at %d: %w", start, err)
 	}
 
-	imgsChan <- respJSON.Response
+	return respJSON.Response, nil
 }
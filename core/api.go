@@ -0,0 +1,190 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// Maximum number of simultaneous page requests.
+const maxPageRequests = 8
+
+// Error response from the SmugMug API.
+type apiErrorJSON struct {
+	Code    int
+	Message string
+}
+
+// apiError is returned when SmugMug responds with a status outside the 2xx
+// range.
+type apiError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *apiError) Error() string {
+	if e.Message == "" {
+		return e.Status
+	}
+	return e.Status + ": " + e.Message
+}
+
+// isNotFound returns true if err is, or wraps, a 404 response from SmugMug.
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// apiGet sends a GET request to uri and decodes the JSON response into v.
+func apiGet(client *http.Client, userToken *oauth.Credentials, uri string,
+	queryParams url.Values, v interface{}) error {
+
+	params := url.Values{
+		"_accept":    {"application/json"},
+		"_verbosity": {"1"},
+	}
+	for key, val := range queryParams {
+		params[key] = val
+	}
+
+	resp, err := oauthClient.Get(client, userToken, uri, params)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(resp, v)
+}
+
+// apiSend sends a request to uri with body encoded as JSON.  body may be nil
+// for requests such as DELETE that don't need one.  If v is not nil, the JSON
+// response is decoded into it.
+func apiSend(client *http.Client, credentials *oauth.Credentials,
+	method string, uri string, body interface{}, v interface{}) error {
+
+	var rawJSON []byte
+	if body != nil {
+		var err error
+		rawJSON, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, uri, bytes.NewReader(rawJSON))
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header["Content-Type"] = []string{"application/json"}
+	}
+	req.Header["Accept"] = []string{"application/json"}
+
+	if err := oauthClient.SetAuthorizationHeader(
+		req.Header, credentials, method, req.URL, url.Values{}); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	return decodeResponse(resp, v)
+}
+
+// decodeResponse reads and closes the response body.  Responses outside the
+// 2xx range are returned as errors using SmugMug's message when available.
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+
+	bytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var errJSON apiErrorJSON
+		json.Unmarshal(bytes, &errJSON)
+		return &apiError{resp.StatusCode, resp.Status, errJSON.Message}
+	}
+
+	if v == nil {
+		return nil
+	}
+
+	return json.Unmarshal(bytes, v)
+}
+
+// remainingPageStarts returns the start index of each page that follows the
+// first page of a paged response.
+func remainingPageStarts(first pagesJSON, pageSize int) []int {
+	starts := make([]int, 0, first.Total/pageSize+1)
+	for start := first.Start + first.Count; start <= first.Total; start += pageSize {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// getPages calls getPage in parallel for each start index.  i is the index of
+// start in starts, so results may be stored in order.  Returns the first
+// error, if any.
+func getPages(starts []int, getPage func(i int, start int) error) error {
+	return inParallel(len(starts), func(i int) error {
+		return getPage(i, starts[i])
+	})
+}
+
+// inParallel calls fn for 0 through n-1, with at most maxPageRequests calls
+// running at once.  Returns the first error, if any.
+func inParallel(n int, fn func(i int) error) error {
+	return inParallelN(n, maxPageRequests, fn)
+}
+
+// inParallelN calls fn for 0 through n-1, with at most workers calls running
+// at once.  Returns the first error, if any.
+func inParallelN(n int, workers int, fn func(i int) error) error {
+	errs := make([]error, n)
+	semaph := make(chan int, workers)
+	waitGrp := sync.WaitGroup{}
+
+	for i := 0; i < n; i++ {
+		waitGrp.Add(1)
+		semaph <- 1
+		go func(i int) {
+			defer waitGrp.Done()
+			errs[i] = fn(i)
+			<-semaph
+		}(i)
+	}
+
+	waitGrp.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
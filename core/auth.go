@@ -97,7 +97,7 @@ func apikey() {
 		return
 	}
 
-	credentials := oauth.Credentials{key, secret}
+	credentials := oauth.Credentials{Token: key, Secret: secret}
 	err := storeAccessData(&credentials, path.Join(smuggoDirFlag, apiTokenFile))
 	if err != nil {
 		fmt.Println("Saving API key: " + err.Error())
@@ -0,0 +1,173 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// Fields requested when listing albums for a backup.
+const albumBackupFilter = albumListFilter + ",UrlPath,ImagesLastUpdated"
+
+// albumBackupDir determines where an album is saved inside a backup.  Albums
+// are laid out as <folder path>/<album name>, using the folder portion of the
+// album's URL path.
+func albumBackupDir(destDir string, album albumJSON) string {
+	folderPath := albumFolderPath(album)
+
+	name := strings.TrimSpace(album.Name)
+	name = strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(name)
+	if name == "" || name == "." || name == ".." {
+		name = album.AlbumKey
+	}
+
+	return filepath.Join(destDir, filepath.FromSlash(folderPath), name)
+}
+
+// albumBackupDirs determines where each album is saved, keyed by album key.
+// Albums in the same folder with the same name, ignoring case, would share a
+// directory, so the album key is added to their directories.
+func albumBackupDirs(destDir string, albums []albumJSON) map[string]string {
+	counts := make(map[string]int, len(albums))
+	for _, album := range albums {
+		counts[strings.ToLower(albumBackupDir(destDir, album))]++
+	}
+
+	dirs := make(map[string]string, len(albums))
+	for _, album := range albums {
+		dir := albumBackupDir(destDir, album)
+		if counts[strings.ToLower(dir)] > 1 {
+			dir += " (" + album.AlbumKey + ")"
+		}
+		dirs[album.AlbumKey] = dir
+	}
+	return dirs
+}
+
+// backup mirrors every album in the user's account to destDir.  Progress is
+// recorded in the DB, so only new or changed images are downloaded and an
+// interrupted backup resumes where it left off.
+func backup(destDir string) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	userURI, err := getUser(userToken)
+	if err != nil {
+		return
+	}
+
+	// Saved paths must not depend on the working directory.
+	destDir, err = filepath.Abs(destDir)
+	if err != nil {
+		log.Println("Error finding backup folder: " + err.Error())
+		return
+	}
+
+	startT := time.Now()
+	var client = http.Client{}
+	albums, err := fetchAlbums(&client, userToken, userURI, albumBackupFilter)
+	if err != nil {
+		log.Println("Error getting albums: " + err.Error())
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	var failed = 0
+	dirs := albumBackupDirs(destDir, albums)
+	for i, album := range albums {
+		fmt.Printf("\n[%d/%d] %s :: %s\n", i+1, len(albums), album.Name, album.AlbumKey)
+		failed += backupAlbum(&client, userToken, db, dirs[album.AlbumKey], album)
+	}
+
+	fmt.Printf("\nBacked up %d albums, %d images not downloaded.\n", len(albums), failed)
+	totalT := time.Since(startT)
+	fmt.Println("Elapsed time: " + totalT.String())
+}
+
+// backupAlbum downloads the album's new and changed images to albumDir.
+// Returns the number of images that could not be downloaded.
+func backupAlbum(client *http.Client, userToken *oauth.Credentials, db *sql.DB,
+	albumDir string, album albumJSON) int {
+
+	if _, err := os.Stat(albumDir); err == nil && album.ImagesLastUpdated != "" &&
+		album.ImagesLastUpdated == getBackupAlbumUpdated(db, album.AlbumKey) {
+		fmt.Println("Up to date.")
+		return 0
+	}
+
+	if err := os.MkdirAll(albumDir, os.ModeDir|0755); err != nil {
+		log.Println("Error creating " + albumDir + ": " + err.Error())
+		return 1
+	}
+
+	images, err := fetchAlbumImages(client, userToken, album.AlbumKey, imageDownloadFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return 1
+	}
+
+	needed := make([]albumImageJSON, 0, len(images))
+	for _, img := range images {
+		hash, prevPath, found := getBackedUpImage(db, album.AlbumKey, img.ImageKey)
+		if !found {
+			needed = append(needed, img)
+			continue
+		}
+
+		// Images saved elsewhere, such as in a directory shared with another
+		// album of the same name, are downloaded again.
+		if _, err := os.Stat(prevPath); err == nil && hash == img.ArchivedMD5 &&
+			filepath.Dir(prevPath) == albumDir {
+			continue
+		}
+
+		// The image was replaced on SmugMug, so remove the old version if it's
+		// still what we saved.
+		if hash != img.ArchivedMD5 {
+			if curHash, _, err := calcMD5(prevPath); err == nil && curHash == hash {
+				os.Remove(prevPath)
+			}
+		}
+		needed = append(needed, img)
+	}
+
+	fmt.Printf("%d of %d images are new or changed.\n", len(needed), len(images))
+	// Filenames taken by other images, or by old versions of an image that
+	// were changed locally, would otherwise fail on every run.
+	failed := downloadImages(client, userToken, needed, albumDir, parallelFlag, true,
+		func(img albumImageJSON, destPath string) {
+			writeBackedUpImage(db, album.AlbumKey, img.ImageKey, img.ArchivedMD5, destPath)
+		})
+
+	if failed == 0 {
+		writeBackupAlbumUpdated(db, album.AlbumKey, album.ImagesLastUpdated)
+	}
+
+	return failed
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAlbumBackupDir(t *testing.T) {
+	album := albumJSON{AlbumKey: "Xk3f9", Name: "Smith Wedding", URLPath: "/Clients/2026/Smith-Wedding"}
+	expected := filepath.Join("backup", "Clients", "2026", "Smith Wedding")
+	actual := albumBackupDir("backup", album)
+
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestAlbumBackupDirTopLevel(t *testing.T) {
+	album := albumJSON{AlbumKey: "Xk3f9", Name: "Cats/Dogs", URLPath: "/Cats-Dogs"}
+	expected := filepath.Join("backup", "Cats_Dogs")
+	actual := albumBackupDir("backup", album)
+
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestAlbumBackupDirNoName(t *testing.T) {
+	album := albumJSON{AlbumKey: "Xk3f9", Name: " ", URLPath: "/Staging/x"}
+	expected := filepath.Join("backup", "Staging", "Xk3f9")
+	actual := albumBackupDir("backup", album)
+
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestAlbumBackupDirsSameName(t *testing.T) {
+	albums := []albumJSON{
+		{AlbumKey: "Xk3f9", Name: "Wedding", URLPath: "/Clients/Wedding"},
+		{AlbumKey: "Pq7r2", Name: "wedding", URLPath: "/Clients/Wedding-2"},
+		{AlbumKey: "Lm4n8", Name: "Wedding", URLPath: "/Family/Wedding"},
+	}
+	expected := map[string]string{
+		"Xk3f9": filepath.Join("backup", "Clients", "Wedding (Xk3f9)"),
+		"Pq7r2": filepath.Join("backup", "Clients", "wedding (Pq7r2)"),
+		"Lm4n8": filepath.Join("backup", "Family", "Wedding"),
+	}
+	actual := albumBackupDirs("backup", albums)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompareFiles(t *testing.T) {
+	files := []localFile{
+		{"milk.jpg", "hash-1"},
+		{"orange.png", "hash-2"},
+		{"copy-of-orange.png", "hash-2"},
+	}
+	images := []albumImageJSON{
+		{ImageKey: "key-1", FileName: "milk.jpg", ArchivedMD5: "hash-1"},
+		{ImageKey: "key-3", FileName: "star.png", ArchivedMD5: "hash-3"},
+	}
+
+	localOnly, remoteOnly := compareFiles(files, images)
+
+	expLocalOnly := []string{"orange.png"}
+	if !reflect.DeepEqual(expLocalOnly, localOnly) {
+		t.Errorf("expected: %s, actual: %s", expLocalOnly, localOnly)
+	}
+
+	if len(remoteOnly) != 1 || remoteOnly[0].ImageKey != "key-3" {
+		t.Errorf("expected only key-3 in album, actual: %v", remoteOnly)
+	}
+}
+
+func TestCompareFilesInSync(t *testing.T) {
+	files := []localFile{{"milk.jpg", "hash-1"}}
+	images := []albumImageJSON{{ImageKey: "key-1", FileName: "milk.jpg", ArchivedMD5: "hash-1"}}
+
+	localOnly, remoteOnly := compareFiles(files, images)
+	if len(localOnly) != 0 {
+		t.Errorf("expected no local only files, actual: %s", localOnly)
+	}
+	if len(remoteOnly) != 0 {
+		t.Errorf("expected no album only images, actual: %v", remoteOnly)
+	}
+}
@@ -21,29 +21,146 @@ import (
 	"log"
 	"os"
 	"path"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 const imageTable = "images"
-const imageTableVersion = 1
+const imageTableVersion = 4
 const imageTableHashIndexName = "images_hash_index"
-const imageTableAblumKeyIndexName = "images_ablum_key_index"
+const imageTableAlbumKeyIndexName = "images_album_key_index"
+
+const backupImageTable = "backup_images"
+const backupImageTableVersion = 1
+const backupAlbumTable = "backup_albums"
+const backupAlbumTableVersion = 1
+
+const albumTable = "albums"
+const albumTableVersion = 1
+
+const uploadTable = "uploads"
+const uploadTableVersion = 1
+
+const uploadHistoryTable = "upload_history"
+const uploadHistoryTableVersion = 1
+
+const imageSyncTable = "image_sync"
+const imageSyncTableVersion = 1
 
 const versionTable = "table_versions"
 
 var imgTableCreateSQL = fmt.Sprintf(
-	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT, hash TEXT, filename TEXT);", imageTable)
-var imgTableHashIndexSQL = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (hash)",
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT, hash TEXT, filename TEXT, "+
+		"image_key TEXT NOT NULL DEFAULT '', uploaded TEXT NOT NULL DEFAULT '');", imageTable)
+var imgTableHashIndexSQL = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (hash);",
 	imageTableHashIndexName, imageTable)
-var imgTableAlbumKeyIndexSQL = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (album_key)",
-	imageTableAblumKeyIndexName, imageTable)
+var imgTableAlbumKeyIndexSQL = fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (album_key);",
+	imageTableAlbumKeyIndexName, imageTable)
 var verTableCreateSQL = fmt.Sprintf(
 	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, name TEXT, version INTEGER);", versionTable)
 
-var imgTableInsertSQL = fmt.Sprintf("INSERT INTO %s (album_key, hash, filename) VALUES (?, ?, ?);", imageTable)
+var backupImgTableCreateSQL = fmt.Sprintf(
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT, image_key TEXT, hash TEXT, path TEXT, "+
+		"UNIQUE (album_key, image_key));", backupImageTable)
+var backupAlbumTableCreateSQL = fmt.Sprintf(
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT UNIQUE, images_last_updated TEXT);",
+	backupAlbumTable)
+
+var albumTableCreateSQL = fmt.Sprintf(
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT UNIQUE, name TEXT, uri TEXT, "+
+		"folder_path TEXT, description TEXT, image_count INTEGER, privacy TEXT, last_updated TEXT);",
+	albumTable)
+
+var uploadTableCreateSQL = fmt.Sprintf(
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT, image_key TEXT, hash TEXT, "+
+		"filename TEXT, uploaded TEXT);", uploadTable)
+
+var uploadHistoryTableCreateSQL = fmt.Sprintf(
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, started TEXT, path TEXT, size INTEGER, album_key TEXT, "+
+		"result TEXT, attempts INTEGER, duration_ms INTEGER, image_uri TEXT, error TEXT);", uploadHistoryTable)
+
+var imageSyncTableCreateSQL = fmt.Sprintf(
+	"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT UNIQUE, images_last_updated TEXT, "+
+		"newest_uploaded TEXT, synced TEXT, full_synced TEXT);", imageSyncTable)
+
+// Tables added after the image table.  These are created when missing from
+// an existing DB.
+var laterTables = []struct {
+	name      string
+	version   int
+	createSQL string
+}{
+	{backupImageTable, backupImageTableVersion, backupImgTableCreateSQL},
+	{backupAlbumTable, backupAlbumTableVersion, backupAlbumTableCreateSQL},
+	{albumTable, albumTableVersion, albumTableCreateSQL},
+	{uploadTable, uploadTableVersion, uploadTableCreateSQL},
+	{uploadHistoryTable, uploadHistoryTableVersion, uploadHistoryTableCreateSQL},
+	{imageSyncTable, imageSyncTableVersion, imageSyncTableCreateSQL},
+}
+
+var imgTableInsertKeySQL = fmt.Sprintf(
+	"INSERT INTO %s (album_key, image_key, hash, filename, uploaded) VALUES (?, ?, ?, ?, ?);", imageTable)
+var imgTableDeleteIDSQL = fmt.Sprintf("DELETE FROM %s WHERE id = ?;", imageTable)
+var imgTableGetRowsSQL = fmt.Sprintf(
+	"SELECT id, image_key, hash, filename, uploaded FROM %s WHERE album_key = ? ORDER BY id;", imageTable)
 var imgTableDeleteSQL = fmt.Sprintf("DELETE FROM %s WHERE album_key = ?;", imageTable)
+var imgTableDeleteImageKeySQL = fmt.Sprintf(
+	"DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE album_key = ? AND image_key = ? LIMIT 1);",
+	imageTable, imageTable)
+var imgTableGetHashRowsSQL = fmt.Sprintf(
+	"SELECT id, image_key, filename FROM %s WHERE album_key = ? AND hash = ? ORDER BY id;", imageTable)
 var imgTableGetDupesSQL = fmt.Sprintf("SELECT filename FROM %s WHERE album_key = ? AND hash = ?", imageTable)
+var imgTableGetAlbumSQL = fmt.Sprintf("SELECT hash, filename FROM %s WHERE album_key = ?;", imageTable)
+var imgTableGetAlbumKeysSQL = fmt.Sprintf("SELECT DISTINCT album_key FROM %s ORDER BY album_key;", imageTable)
+var imgTableGetOtherAlbumsSQL = fmt.Sprintf(
+	"SELECT DISTINCT album_key FROM %s WHERE hash = ? AND album_key != ?;", imageTable)
+
+var albumTableWriteSQL = fmt.Sprintf(
+	"INSERT OR REPLACE INTO %s (album_key, name, uri, folder_path, description, image_count, privacy, last_updated) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?);", albumTable)
+
+var albumTableDeleteAllSQL = fmt.Sprintf("DELETE FROM %s;", albumTable)
+var albumTableGetAllSQL = fmt.Sprintf(
+	"SELECT album_key, name, uri, folder_path, description, image_count, privacy, last_updated FROM %s;",
+	albumTable)
+var albumTableDeleteSQL = fmt.Sprintf("DELETE FROM %s WHERE album_key = ?;", albumTable)
+var albumTableMoveFolderSQL = fmt.Sprintf(
+	"UPDATE %s SET folder_path = ? || substr(folder_path, ?) WHERE folder_path = ? OR substr(folder_path, 1, ?) = ?;",
+	albumTable)
+var albumTableCountSQL = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE album_key = ?;", albumTable)
+
+var uploadTableWriteSQL = fmt.Sprintf(
+	"INSERT INTO %s (album_key, image_key, hash, filename, uploaded) VALUES (?, ?, ?, ?, ?);", uploadTable)
+var uploadTableGetSQL = fmt.Sprintf(
+	"SELECT image_key, hash, filename, uploaded FROM %s WHERE album_key = ?;", uploadTable)
+
+var uploadHistoryTableWriteSQL = fmt.Sprintf(
+	"INSERT INTO %s (started, path, size, album_key, result, attempts, duration_ms, image_uri, error) "+
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);", uploadHistoryTable)
+var uploadHistoryTableGetSQL = fmt.Sprintf(
+	"SELECT started, path, size, album_key, result, attempts, duration_ms, image_uri, error FROM %s "+
+		"WHERE ? = '' OR album_key = ? ORDER BY id;", uploadHistoryTable)
+
+var imageSyncTableGetSQL = fmt.Sprintf(
+	"SELECT images_last_updated, newest_uploaded, synced, full_synced FROM %s WHERE album_key = ?;",
+	imageSyncTable)
+var imageSyncTableWriteSQL = fmt.Sprintf(
+	"INSERT OR REPLACE INTO %s (album_key, images_last_updated, newest_uploaded, synced, full_synced) "+
+		"VALUES (?, ?, ?, ?, ?);", imageSyncTable)
+var imageSyncTableDeleteSQL = fmt.Sprintf("DELETE FROM %s WHERE album_key = ?;", imageSyncTable)
+
+var backupImgTableGetSQL = fmt.Sprintf(
+	"SELECT hash, path FROM %s WHERE album_key = ? AND image_key = ?;", backupImageTable)
+var backupImgTableGetPathsSQL = fmt.Sprintf("SELECT path FROM %s WHERE hash = ?;", backupImageTable)
+var backupImgTableWriteSQL = fmt.Sprintf(
+	"INSERT OR REPLACE INTO %s (album_key, image_key, hash, path) VALUES (?, ?, ?, ?);", backupImageTable)
+var backupAlbumTableGetSQL = fmt.Sprintf(
+	"SELECT images_last_updated FROM %s WHERE album_key = ?;", backupAlbumTable)
+var backupAlbumTableWriteSQL = fmt.Sprintf(
+	"INSERT OR REPLACE INTO %s (album_key, images_last_updated) VALUES (?, ?);", backupAlbumTable)
 
 // Ensure DB exists and is a compatible version.
 func initDB() {
@@ -64,6 +181,13 @@ func initDB() {
 		createTables(db, imageTableVersion)
 	}
 
+	createLaterTables(db)
+
+	backupFile := fmt.Sprintf("%s.%s.bak", dbFile, time.Now().Format("20060102-150405"))
+	if err := migrateTables(db, backupFile); err != nil {
+		log.Fatal(err)
+	}
+
 	if err := validateTables(db); err != nil {
 		log.Fatal(err)
 	}
@@ -80,11 +204,13 @@ func openDB() *sql.DB {
 	return db
 }
 
-// Create tables and indices for an empty DB.
-// If more tables added, will need to rethink accepting a single version
-// parameter.
+// Create tables and indices for an empty DB.  Tables are created in their
+// latest form, but the image table is recorded as imgTableVersion so tests
+// can create out of date DBs.  Other tables are recorded at their latest
+// version.
 func createTables(db *sql.DB, imgTableVersion int) {
-	createSQL := fmt.Sprintf("%s\n%s\n%s", imgTableCreateSQL, verTableCreateSQL, imgTableHashIndexSQL)
+	createSQL := fmt.Sprintf("%s\n%s\n%s\n%s", imgTableCreateSQL, verTableCreateSQL,
+		imgTableHashIndexSQL, imgTableAlbumKeyIndexSQL)
 
 	_, err := db.Exec(createSQL)
 	if err != nil {
@@ -109,26 +235,43 @@ func createTables(db *sql.DB, imgTableVersion int) {
 	}
 
 	tx.Commit()
+
+	createLaterTables(db)
+}
+
+// Create any tables added after the image table that don't exist, yet.
+func createLaterTables(db *sql.DB) {
+	for _, table := range laterTables {
+		var count int
+		row := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;", table.name)
+		if err := row.Scan(&count); err != nil {
+			log.Fatal(err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(table.createSQL); err != nil {
+			log.Fatalf("Error creating database table %s: %q\n", table.name, err)
+		}
+
+		_, err := db.Exec(
+			fmt.Sprintf("INSERT INTO %s (name, version) VALUES (?, ?);", versionTable), table.name, table.version)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
 func validateTables(db *sql.DB) error {
-	rows, err := db.Query(fmt.Sprintf("SELECT name, version FROM %s;", versionTable))
+	versions, err := getTableVersions(db)
 	if err != nil {
-		//fmt.Sprintf("Error validating table versions: %q\n", err)
 		return err
 	}
 
-	defer rows.Close()
-	for rows.Next() {
-		var name string
-		var version int
-		err = rows.Scan(&name, &version)
-		if err != nil {
-			return err
-		}
-
-		if name == imageTable && version != imageTableVersion {
-			msg := fmt.Sprintf("Table: %s version is %d, but must be version %d", imageTable, version, imageTableVersion)
+	for name, latestVersion := range latestTableVersions() {
+		if version, found := versions[name]; found && version != latestVersion {
+			msg := fmt.Sprintf("Table: %s version is %d, but must be version %d", name, version, latestVersion)
 			return errors.New(msg)
 		}
 	}
@@ -136,56 +279,194 @@ func validateTables(db *sql.DB) error {
 	return nil
 }
 
-// Write image data for the given images to the DB.
-func writeImageData(db *sql.DB, albumKey string, imgData []imageJSON) {
+// Remove all image data for the given album.
+func removeAlbumImages(db *sql.DB, albumKey string) {
 	tx, err := db.Begin()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	insertSQL, err := tx.Prepare(imgTableInsertSQL)
+	deleteSQL, err := tx.Prepare(imgTableDeleteSQL)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	defer insertSQL.Close()
-	for _, row := range imgData {
-		_, err = insertSQL.Exec(albumKey, row.ArchivedMD5, row.FileName)
-		if err != nil {
-			rollbackErr := tx.Rollback()
-			if rollbackErr != nil {
-				log.Fatalf("Failed to insert image data: %v, rollback failed: %v", err, rollbackErr)
-			}
-			log.Fatal(err)
+	defer deleteSQL.Close()
+	_, err = deleteSQL.Exec(albumKey)
+	if err == nil {
+		// Without rows, the album must be fully refreshed next time.
+		_, err = tx.Exec(imageSyncTableDeleteSQL, albumKey)
+	}
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			log.Fatalf("Failed deleting image data for album: %s: %v, rollback failed: %v\n",
+				albumKey, err, rollbackErr)
 		}
+		log.Fatal(err)
 	}
 
 	tx.Commit()
 }
 
-// Remove all image data for the given album.
-func removeAlbumImages(db *sql.DB, albumKey string) {
-	tx, err := db.Begin()
+// Remove the image data for a single image in the given album.  The row is
+// found by image key.  Rows without a key, or images without one, are matched
+// by hash and the base of the filename, since rows written for uploads hold
+// the local path.  If the album has duplicates of the image, only one of them
+// is removed.
+func removeImageData(db *sql.DB, albumKey string, img albumImageJSON) {
+	if img.ImageKey != "" {
+		result, err := db.Exec(imgTableDeleteImageKeySQL, albumKey, img.ImageKey)
+		if err != nil {
+			log.Printf("Failed deleting image data for album: %s, file: %s: %v\n",
+				albumKey, img.FileName, err)
+			return
+		}
+		if count, err := result.RowsAffected(); err == nil && count > 0 {
+			return
+		}
+	}
+
+	rows, err := db.Query(imgTableGetHashRowsSQL, albumKey, img.ArchivedMD5)
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("Failed deleting image data for album: %s, file: %s: %v\n",
+			albumKey, img.FileName, err)
+		return
 	}
 
-	deleteSQL, err := tx.Prepare(imgTableDeleteSQL)
+	name := filepath.Base(filepath.FromSlash(img.FileName))
+	var id int64 = -1
+	for rows.Next() {
+		var rowID int64
+		var imageKey, filename string
+		if err := rows.Scan(&rowID, &imageKey, &filename); err != nil {
+			log.Println(err)
+			continue
+		}
+		if (img.ImageKey == "" || imageKey == "") && filepath.Base(filepath.FromSlash(filename)) == name {
+			id = rowID
+			break
+		}
+	}
+	rows.Close()
+
+	if id < 0 {
+		return
+	}
+	if _, err := db.Exec(imgTableDeleteIDSQL, id); err != nil {
+		log.Printf("Failed deleting image data for album: %s, file: %s: %v\n",
+			albumKey, img.FileName, err)
+	}
+}
+
+// imageRow is a row of the image table.  ImageKey is empty for rows written
+// before image keys were stored.
+type imageRow struct {
+	ID          int64
+	ImageKey    string
+	ArchivedMD5 string
+	FileName    string
+	Uploaded    string // DateTimeUploaded, empty until fetched from SmugMug.
+}
+
+// imageRowDiff lists the changes that bring an album's rows up to date.
+type imageRowDiff struct {
+	Deletes []int64 // IDs of rows to remove.
+	Inserts []albumImageJSON
+}
+
+// imageSync records when an album's rows were last refreshed.  Times are
+// RFC 3339.
+type imageSync struct {
+	ImagesLastUpdated string // The album's ImagesLastUpdated when refreshed.
+	NewestUploaded    string // Latest DateTimeUploaded of the album's images.
+	Synced            string // Time of the last refresh.
+	FullSynced        string // Time of the last refresh of every image.
+}
+
+// Write images, with their image keys and upload times, to the image table.
+func writeAlbumImages(db *sql.DB, albumKey string, images []albumImageJSON) {
+	applyImageDiff(db, albumKey, imageRowDiff{Inserts: images}, nil)
+}
+
+// Get the rows of the image table for an album.
+func getImageRows(db *sql.DB, albumKey string) []imageRow {
+	rows := make([]imageRow, 0, 100)
+	results, err := db.Query(imgTableGetRowsSQL, albumKey)
 	if err != nil {
-		log.Fatal(err)
+		log.Println("Error reading image data: " + err.Error())
+		return rows
 	}
 
-	defer deleteSQL.Close()
-	_, err = deleteSQL.Exec(albumKey)
+	defer results.Close()
+	for results.Next() {
+		var row imageRow
+		if err := results.Scan(&row.ID, &row.ImageKey, &row.ArchivedMD5, &row.FileName, &row.Uploaded); err != nil {
+			log.Println(err)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// Get when an album's rows were last refreshed.  Returns false if they never
+// were.
+func getImageSync(db *sql.DB, albumKey string) (imageSync, bool) {
+	var sync imageSync
+	row := db.QueryRow(imageSyncTableGetSQL, albumKey)
+	if err := row.Scan(&sync.ImagesLastUpdated, &sync.NewestUploaded, &sync.Synced, &sync.FullSynced); err != nil {
+		if err != sql.ErrNoRows {
+			log.Println("Error reading image sync time: " + err.Error())
+		}
+		return sync, false
+	}
+	return sync, true
+}
+
+// Apply changes to an album's rows and, if sync isn't nil, record the refresh
+// in a single transaction.
+func applyImageDiff(db *sql.DB, albumKey string, diff imageRowDiff, sync *imageSync) {
+	tx, err := db.Begin()
 	if err != nil {
-		rollbackErr := tx.Rollback()
-		if rollbackErr != nil {
-			log.Fatalf("Failed deleting image data for album: %s: %v, rollback failed: %v\n",
+		log.Fatal(err)
+	}
+
+	fail := func(err error) {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Fatalf("Failed updating image data for album: %s: %v, rollback failed: %v\n",
 				albumKey, err, rollbackErr)
 		}
 		log.Fatal(err)
 	}
 
+	for _, id := range diff.Deletes {
+		if _, err := tx.Exec(imgTableDeleteIDSQL, id); err != nil {
+			fail(err)
+		}
+	}
+
+	insertSQL, err := tx.Prepare(imgTableInsertKeySQL)
+	if err != nil {
+		fail(err)
+	}
+
+	defer insertSQL.Close()
+	for _, img := range diff.Inserts {
+		if _, err := insertSQL.Exec(albumKey, img.ImageKey, img.ArchivedMD5, img.FileName, img.DateTimeUploaded); err != nil {
+			fail(err)
+		}
+	}
+
+	if sync != nil {
+		_, err := tx.Exec(imageSyncTableWriteSQL, albumKey, sync.ImagesLastUpdated, sync.NewestUploaded,
+			sync.Synced, sync.FullSynced)
+		if err != nil {
+			fail(err)
+		}
+	}
+
 	tx.Commit()
 }
 
@@ -210,3 +491,285 @@ func getDuplicateImages(db *sql.DB, albumKey string, hash string) []string {
 
 	return filenames
 }
+
+// Get the hash and local path of an image saved by a previous backup.  Returns
+// false if the image hasn't been backed up.
+func getBackedUpImage(db *sql.DB, albumKey string, imageKey string) (string, string, bool) {
+	var hash, path string
+	err := db.QueryRow(backupImgTableGetSQL, albumKey, imageKey).Scan(&hash, &path)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Println(err)
+		}
+		return "", "", false
+	}
+
+	return hash, path, true
+}
+
+// Get the local paths where images with the given hash were saved by
+// backups.  The files may have been removed since.
+func getBackedUpPaths(db *sql.DB, hash string) []string {
+	return queryStrings(db, backupImgTableGetPathsSQL, hash)
+}
+
+// Record that an image was saved to the given local path.
+func writeBackedUpImage(db *sql.DB, albumKey string, imageKey string, hash string, path string) {
+	_, err := db.Exec(backupImgTableWriteSQL, albumKey, imageKey, hash, path)
+	if err != nil {
+		log.Printf("Failed recording backup of image: %s: %v\n", imageKey, err)
+	}
+}
+
+// Get when an album's images were last updated as of its last complete
+// backup.  Returns an empty string if the album hasn't been backed up.
+func getBackupAlbumUpdated(db *sql.DB, albumKey string) string {
+	var updated string
+	err := db.QueryRow(backupAlbumTableGetSQL, albumKey).Scan(&updated)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println(err)
+	}
+
+	return updated
+}
+
+// Record a complete backup of an album whose images were last updated at the
+// given time.
+func writeBackupAlbumUpdated(db *sql.DB, albumKey string, updated string) {
+	_, err := db.Exec(backupAlbumTableWriteSQL, albumKey, updated)
+	if err != nil {
+		log.Printf("Failed recording backup of album: %s: %v\n", albumKey, err)
+	}
+}
+
+// Write album data for the given albums to the DB, replacing any existing data
+// for the same albums.
+func writeAlbumData(db *sql.DB, albums []albumJSON) {
+	saveAlbumData(db, albums, false)
+}
+
+// Replace the album data for all albums with the given albums.
+func replaceAlbumData(db *sql.DB, albums []albumJSON) {
+	saveAlbumData(db, albums, true)
+}
+
+// Write album data in a single transaction, first removing the data for all
+// albums if deleteAll is true.
+func saveAlbumData(db *sql.DB, albums []albumJSON, deleteAll bool) {
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if deleteAll {
+		if _, err := tx.Exec(albumTableDeleteAllSQL); err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				log.Fatalf("Failed to delete album data: %v, rollback failed: %v", err, rollbackErr)
+			}
+			log.Fatal(err)
+		}
+	}
+
+	writeSQL, err := tx.Prepare(albumTableWriteSQL)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer writeSQL.Close()
+	for _, album := range albums {
+		_, err = writeSQL.Exec(album.AlbumKey, album.Name, album.URI, albumFolderPath(album),
+			album.Description, album.ImageCount, album.Privacy, album.LastUpdated)
+		if err != nil {
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				log.Fatalf("Failed to write album data: %v, rollback failed: %v", err, rollbackErr)
+			}
+			log.Fatal(err)
+		}
+	}
+
+	tx.Commit()
+}
+
+// Get the album data for all albums.
+func getAlbumData(db *sql.DB) []albumJSON {
+	albums := make([]albumJSON, 0, 100)
+	rows, err := db.Query(albumTableGetAllSQL)
+	if err != nil {
+		log.Println("Error reading album data: " + err.Error())
+		return albums
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var album albumJSON
+		err = rows.Scan(&album.AlbumKey, &album.Name, &album.URI, &album.FolderPath,
+			&album.Description, &album.ImageCount, &album.Privacy, &album.LastUpdated)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		albums = append(albums, album)
+	}
+
+	return albums
+}
+
+// Remove the album data for the given album.
+func removeAlbumData(db *sql.DB, albumKey string) {
+	_, err := db.Exec(albumTableDeleteSQL, albumKey)
+	if err != nil {
+		log.Printf("Failed deleting album data for album: %s: %v\n", albumKey, err)
+	}
+}
+
+// Returns true if the DB has album data for the given album.
+func isAlbumCached(db *sql.DB, albumKey string) bool {
+	var count int
+	if err := db.QueryRow(albumTableCountSQL, albumKey).Scan(&count); err != nil {
+		log.Println(err)
+		return false
+	}
+
+	return count > 0
+}
+
+// Change the folder path of albums in the folder at oldPath, or any folder
+// below it, to start with newPath.
+func moveAlbumFolderData(db *sql.DB, oldPath string, newPath string) {
+	// SQLite counts characters, not bytes, and the prefix is compared exactly,
+	// so characters like _ and % in folder names aren't treated as wildcards.
+	oldLen := utf8.RuneCountInString(oldPath)
+	_, err := db.Exec(albumTableMoveFolderSQL, newPath, oldLen+1, oldPath, oldLen+1, oldPath+"/")
+	if err != nil {
+		log.Printf("Failed moving album data from folder: %s: %v\n", oldPath, err)
+	}
+}
+
+// uploadRecord is an image that smuggo uploaded.
+type uploadRecord struct {
+	ImageKey string
+	Hash     string
+	FileName string
+	Uploaded string
+}
+
+// Record that smuggo uploaded an image to an album.
+func writeUpload(db *sql.DB, albumKey string, upload uploadRecord) {
+	_, err := db.Exec(uploadTableWriteSQL, albumKey, upload.ImageKey, upload.Hash, upload.FileName, upload.Uploaded)
+	if err != nil {
+		log.Printf("Failed recording upload of image: %s: %v\n", upload.FileName, err)
+	}
+}
+
+// Get the images smuggo uploaded to an album.
+func getUploads(db *sql.DB, albumKey string) []uploadRecord {
+	uploads := make([]uploadRecord, 0, 100)
+	rows, err := db.Query(uploadTableGetSQL, albumKey)
+	if err != nil {
+		log.Println("Error reading uploads: " + err.Error())
+		return uploads
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var upload uploadRecord
+		if err := rows.Scan(&upload.ImageKey, &upload.Hash, &upload.FileName, &upload.Uploaded); err != nil {
+			log.Println(err)
+			continue
+		}
+		uploads = append(uploads, upload)
+	}
+
+	return uploads
+}
+
+// Record an attempt to upload an image, whether or not it succeeded.
+func writeUploadAttempt(db *sql.DB, attempt uploadAttempt) {
+	_, err := db.Exec(uploadHistoryTableWriteSQL, attempt.Started, attempt.Path, attempt.Size, attempt.AlbumKey,
+		attempt.Result, attempt.Attempts, attempt.DurationMs, attempt.ImageURI, attempt.Error)
+	if err != nil {
+		log.Printf("Failed recording upload history of image: %s: %v\n", attempt.Path, err)
+	}
+}
+
+// Get the recorded upload attempts, oldest first, for an album or for every
+// album if albumKey is empty.
+func getUploadHistory(db *sql.DB, albumKey string) []uploadAttempt {
+	attempts := make([]uploadAttempt, 0, 100)
+	rows, err := db.Query(uploadHistoryTableGetSQL, albumKey, albumKey)
+	if err != nil {
+		log.Println("Error reading upload history: " + err.Error())
+		return attempts
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var a uploadAttempt
+		if err := rows.Scan(&a.Started, &a.Path, &a.Size, &a.AlbumKey, &a.Result, &a.Attempts,
+			&a.DurationMs, &a.ImageURI, &a.Error); err != nil {
+			log.Println(err)
+			continue
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts
+}
+
+// Get the keys of albums other than the given album that have an image with
+// the given hash.
+func getOtherAlbumsWithImage(db *sql.DB, albumKey string, hash string) []string {
+	return queryStrings(db, imgTableGetOtherAlbumsSQL, hash, albumKey)
+}
+
+// queryStrings runs a query that selects a single text column and returns the
+// values.
+func queryStrings(db *sql.DB, query string, args ...interface{}) []string {
+	values := make([]string, 0, 5)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Println(err)
+		return values
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			log.Println(err)
+			continue
+		}
+		values = append(values, value)
+	}
+
+	return values
+}
+
+// Get the image data stored for an album.
+func getAlbumImageData(db *sql.DB, albumKey string) []imageJSON {
+	images := make([]imageJSON, 0, 100)
+	rows, err := db.Query(imgTableGetAlbumSQL, albumKey)
+	if err != nil {
+		log.Println("Error reading image data: " + err.Error())
+		return images
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var img imageJSON
+		if err := rows.Scan(&img.ArchivedMD5, &img.FileName); err != nil {
+			log.Println(err)
+			continue
+		}
+		images = append(images, img)
+	}
+
+	return images
+}
+
+// Get the keys of every album with image data.
+func getImageAlbumKeys(db *sql.DB) []string {
+	return queryStrings(db, imgTableGetAlbumKeysSQL)
+}
@@ -17,6 +17,7 @@ package main
 import (
 	"database/sql"
 	"fmt"
+	"reflect"
 	"testing"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -33,17 +34,20 @@ func TestVersionMismatch(t *testing.T) {
 	}
 }
 
-func TestWriteImageData(t *testing.T) {
+func TestWriteAlbumImages(t *testing.T) {
 	db := setUpTestDB(t)
 	defer db.Close()
 
 	createTables(db, imageTableVersion)
 
 	albumKey := "fake-album-key"
-	imgData := []imageJSON{{"fake-hash-1", "img1.jpg"}, {"fake-hash-2", "img2.jpg"}}
-	writeImageData(db, albumKey, imgData)
+	imgData := []albumImageJSON{
+		{ImageKey: "key-1", ArchivedMD5: "fake-hash-1", FileName: "img1.jpg", DateTimeUploaded: "2026-10-01T10:00:00+00:00"},
+		{ImageKey: "key-2", ArchivedMD5: "fake-hash-2", FileName: "img2.jpg"},
+	}
+	writeAlbumImages(db, albumKey, imgData)
 
-	rows, err := db.Query(fmt.Sprintf("SELECT album_key, hash, filename FROM %s;", imageTable))
+	rows, err := db.Query(fmt.Sprintf("SELECT album_key, image_key, hash, filename, uploaded FROM %s;", imageTable))
 	if err != nil {
 		t.Error(err)
 	}
@@ -52,15 +56,23 @@ func TestWriteImageData(t *testing.T) {
 	var ind = 0
 	for rows.Next() {
 		var actualAlbumKey string
+		var actualImageKey string
 		var actualHash string
 		var actualFilename string
-		err = rows.Scan(&actualAlbumKey, &actualHash, &actualFilename)
+		var actualUploaded string
+		err = rows.Scan(&actualAlbumKey, &actualImageKey, &actualHash, &actualFilename, &actualUploaded)
 		if err != nil {
 			t.Error(err)
 		}
 		if actualAlbumKey != albumKey {
 			t.Errorf("Expected album key %s, got %s\n", albumKey, actualAlbumKey)
 		}
+		if actualImageKey != imgData[ind].ImageKey {
+			t.Errorf("Expected image key %s, got %s\n", imgData[ind].ImageKey, actualImageKey)
+		}
+		if actualUploaded != imgData[ind].DateTimeUploaded {
+			t.Errorf("Expected upload time %s, got %s\n", imgData[ind].DateTimeUploaded, actualUploaded)
+		}
 		if actualHash != imgData[ind].ArchivedMD5 {
 			t.Errorf("Expected hash %s, got %s\n", actualHash, imgData[ind].ArchivedMD5)
 		}
@@ -81,11 +93,11 @@ func TestRetrieveHash(t *testing.T) {
 	expHash := "fake-hash-1"
 	expFilename := "img1.jpg"
 	imgData := []imageJSON{{expHash, expFilename}, {"fake-hash-2", "img2.jpg"}}
-	writeImageData(db, albumKey1, imgData)
+	writeTestImageData(db, albumKey1, imgData)
 
 	albumKey2 := "fake-album-key2"
 	imgData2 := []imageJSON{{expHash, expFilename}}
-	writeImageData(db, albumKey2, imgData2)
+	writeTestImageData(db, albumKey2, imgData2)
 
 	rows, err := db.Query(
 		fmt.Sprintf("SELECT album_key, hash, filename FROM %s WHERE hash = ?;", imageTable), expHash)
@@ -144,11 +156,11 @@ func TestRemoveAlbumImages(t *testing.T) {
 	expHash := "fake-hash-1"
 	expFilename := "img1.jpg"
 	imgData := []imageJSON{{expHash, expFilename}, {"fake-hash-2", "img2.jpg"}}
-	writeImageData(db, albumKey1, imgData)
+	writeTestImageData(db, albumKey1, imgData)
 
 	albumKey2 := "fake-album-key2"
 	imgData2 := []imageJSON{{expHash, expFilename}}
-	writeImageData(db, albumKey2, imgData2)
+	writeTestImageData(db, albumKey2, imgData2)
 
 	removeAlbumImages(db, albumKey1)
 
@@ -181,7 +193,7 @@ func TestGetDuplicateImages(t *testing.T) {
 	expFilename1 := "img1.jpg"
 	expFilename2 := "img2.jpg"
 	imgData := []imageJSON{{expHash, expFilename1}, {expHash, expFilename2}}
-	writeImageData(db, albumKey, imgData)
+	writeTestImageData(db, albumKey, imgData)
 
 	actualFilenames := getDuplicateImages(db, albumKey, expHash)
 	if len(actualFilenames) != 2 {
@@ -208,6 +220,241 @@ func TestGetDuplicateImages(t *testing.T) {
 	}
 }
 
+func TestBackedUpImage(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	albumKey := "fake-album-key"
+	imageKey := "fake-image-key"
+	if _, _, found := getBackedUpImage(db, albumKey, imageKey); found {
+		t.Error("Image should not be backed up, yet")
+	}
+
+	writeBackedUpImage(db, albumKey, imageKey, "fake-hash-1", "/backup/img1.jpg")
+	writeBackedUpImage(db, albumKey, imageKey, "fake-hash-2", "/backup/img1.jpg")
+
+	hash, path, found := getBackedUpImage(db, albumKey, imageKey)
+	if !found {
+		t.Error("Did not find backed up image")
+	}
+	if hash != "fake-hash-2" {
+		t.Errorf("Expected hash %s, got %s\n", "fake-hash-2", hash)
+	}
+	if path != "/backup/img1.jpg" {
+		t.Errorf("Expected path %s, got %s\n", "/backup/img1.jpg", path)
+	}
+}
+
+func TestBackupAlbumUpdated(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	albumKey := "fake-album-key"
+	if updated := getBackupAlbumUpdated(db, albumKey); updated != "" {
+		t.Errorf("Expected no update time, got %s", updated)
+	}
+
+	expUpdated := "2026-10-18T12:00:00+00:00"
+	writeBackupAlbumUpdated(db, albumKey, expUpdated)
+	if updated := getBackupAlbumUpdated(db, albumKey); updated != expUpdated {
+		t.Errorf("Expected update time %s, got %s", expUpdated, updated)
+	}
+}
+
+func TestCreateLaterTables(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	createLaterTables(db)
+
+	if err := validateTables(db); err != nil {
+		t.Error(err)
+	}
+
+	row := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s;", versionTable))
+	var count int
+	if err := row.Scan(&count); err != nil {
+		t.Error(err)
+	}
+	if count != len(laterTables)+1 {
+		t.Errorf("Expected %d table versions but found %d", len(laterTables)+1, count)
+	}
+}
+
+func TestWriteAlbumData(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	album := albumJSON{AlbumKey: "fake-album-key", Name: "Old Name", URLPath: "/Clients/2026/Old-Name"}
+	writeAlbumData(db, []albumJSON{album})
+	album.Name = "New Name"
+	writeAlbumData(db, []albumJSON{album})
+
+	rows, err := db.Query(fmt.Sprintf("SELECT name, folder_path FROM %s WHERE album_key = ?;", albumTable),
+		album.AlbumKey)
+	if err != nil {
+		t.Error(err)
+	}
+
+	defer rows.Close()
+	var count = 0
+	for rows.Next() {
+		count++
+		var name, folderPath string
+		if err := rows.Scan(&name, &folderPath); err != nil {
+			t.Error(err)
+		}
+		if name != album.Name {
+			t.Errorf("Expected name %s, got %s\n", album.Name, name)
+		}
+		if folderPath != "Clients/2026" {
+			t.Errorf("Expected folder path %s, got %s\n", "Clients/2026", folderPath)
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 row in album table but found %d", count)
+	}
+}
+
+func TestReplaceAlbumData(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	writeAlbumData(db, []albumJSON{{AlbumKey: "deleted-album-key", Name: "Deleted"}})
+	expAlbum := albumJSON{
+		AlbumKey:    "fake-album-key",
+		Name:        "Smith Wedding",
+		URI:         "/api/v2/album/fake-album-key",
+		URLPath:     "/Clients/2026/Smith-Wedding",
+		Description: "Final selects",
+		ImageCount:  120,
+		Privacy:     privacyPrivate,
+		LastUpdated: "2026-10-18T12:00:00+00:00",
+	}
+	replaceAlbumData(db, []albumJSON{expAlbum})
+
+	albums := getAlbumData(db)
+	if len(albums) != 1 {
+		t.Fatalf("Expected 1 album but found %d", len(albums))
+	}
+
+	expAlbum.URLPath = ""
+	expAlbum.FolderPath = "Clients/2026"
+	if albums[0] != expAlbum {
+		t.Errorf("Expected album %v, got %v\n", expAlbum, albums[0])
+	}
+}
+
+func TestMoveAlbumFolderData(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	writeAlbumData(db, []albumJSON{
+		{AlbumKey: "key-1", URLPath: "/Staging/Smith-Wedding"},
+		{AlbumKey: "key-2", URLPath: "/Staging/Jones/Portraits"},
+		{AlbumKey: "key-3", URLPath: "/Staging-Old/Brown"},
+	})
+
+	moveAlbumFolderData(db, "Staging", "Clients/2026/Staging")
+
+	expected := map[string]string{
+		"key-1": "Clients/2026/Staging",
+		"key-2": "Clients/2026/Staging/Jones",
+		"key-3": "Staging-Old",
+	}
+	for albumKey, expPath := range expected {
+		var folderPath string
+		row := db.QueryRow(fmt.Sprintf("SELECT folder_path FROM %s WHERE album_key = ?;", albumTable), albumKey)
+		if err := row.Scan(&folderPath); err != nil {
+			t.Error(err)
+		}
+		if folderPath != expPath {
+			t.Errorf("Expected folder path %s for %s, got %s\n", expPath, albumKey, folderPath)
+		}
+	}
+}
+
+func TestMoveAlbumFolderDataWildcards(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	writeAlbumData(db, []albumJSON{
+		{AlbumKey: "key-1", FolderPath: "Stag_ng/Café"},
+		{AlbumKey: "key-2", FolderPath: "Staging/Café"},
+		{AlbumKey: "key-3", FolderPath: "Stag%/Café"},
+	})
+
+	moveAlbumFolderData(db, "Stag_ng", "Archive/Été")
+
+	expected := map[string]string{
+		"key-1": "Archive/Été/Café",
+		"key-2": "Staging/Café",
+		"key-3": "Stag%/Café",
+	}
+	for albumKey, expPath := range expected {
+		var folderPath string
+		row := db.QueryRow(fmt.Sprintf("SELECT folder_path FROM %s WHERE album_key = ?;", albumTable), albumKey)
+		if err := row.Scan(&folderPath); err != nil {
+			t.Error(err)
+		}
+		if folderPath != expPath {
+			t.Errorf("Expected folder path %s for %s, got %s\n", expPath, albumKey, folderPath)
+		}
+	}
+}
+
+func TestRemoveImageData(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	// Uploads store the local path as the filename.
+	writeAlbumImages(db, "album", []albumImageJSON{
+		{ImageKey: "key-1", ArchivedMD5: "hash-1", FileName: "/home/me/milk.jpg"},
+		{ArchivedMD5: "hash-2", FileName: "/home/me/rose.jpg"},
+		{ArchivedMD5: "hash-2", FileName: "/home/me/other.jpg"},
+		{ImageKey: "key-3", ArchivedMD5: "hash-3", FileName: "/home/me/leaf.jpg"},
+	})
+
+	removeImageData(db, "album", albumImageJSON{ImageKey: "key-1", ArchivedMD5: "hash-1", FileName: "milk.jpg"})
+	removeImageData(db, "album", albumImageJSON{ImageKey: "key-2", ArchivedMD5: "hash-2", FileName: "rose.jpg"})
+	// A row with a different image key is a different image.
+	removeImageData(db, "album", albumImageJSON{ImageKey: "key-4", ArchivedMD5: "hash-3", FileName: "leaf.jpg"})
+
+	names := make([]string, 0, 2)
+	for _, row := range getImageRows(db, "album") {
+		names = append(names, row.FileName)
+	}
+	expected := []string{"/home/me/other.jpg", "/home/me/leaf.jpg"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Errorf("expected: %v, actual: %v", expected, names)
+	}
+}
+
+// writeTestImageData writes rows with only a hash and filename, like the rows
+// written before image keys were stored.
+func writeTestImageData(db *sql.DB, albumKey string, imgData []imageJSON) {
+	images := make([]albumImageJSON, 0, len(imgData))
+	for _, row := range imgData {
+		images = append(images, albumImageJSON{ArchivedMD5: row.ArchivedMD5, FileName: row.FileName})
+	}
+	writeAlbumImages(db, albumKey, images)
+}
+
 func setUpTestDB(t *testing.T) *sql.DB {
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
@@ -215,3 +462,28 @@ func setUpTestDB(t *testing.T) *sql.DB {
 	}
 	return db
 }
+
+func TestUploads(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	expUpload := uploadRecord{
+		ImageKey: "fake-image-key",
+		Hash:     "fake-hash",
+		FileName: "milk.jpg",
+		Uploaded: "2026-10-18T12:00:00Z",
+	}
+	writeUpload(db, "fake-album-key", expUpload)
+	writeUpload(db, "other-album-key", uploadRecord{ImageKey: "other-image-key"})
+
+	uploads := getUploads(db, "fake-album-key")
+	if len(uploads) != 1 {
+		t.Fatalf("Expected 1 upload but found %d", len(uploads))
+	}
+
+	if uploads[0] != expUpload {
+		t.Errorf("Expected upload %v, got %v\n", expUpload, uploads[0])
+	}
+}
@@ -0,0 +1,614 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Driver used to get the SQLite connections needed for an online backup.
+const backupDriverName = "sqlite3_backup"
+
+// Connections opened with the backup driver, in the order they're opened.
+var backupDriverConns = make(chan *sqlite3.SQLiteConn, 2)
+
+// Number of pages copied at a time by an online backup, so the DB isn't
+// locked for the whole backup.
+const backupStepPages = 100
+
+func init() {
+	sql.Register(backupDriverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			select {
+			case backupDriverConns <- conn:
+			default:
+			}
+			return nil
+		},
+	})
+}
+
+// tableDump holds the rows of a table, without the id column, so they can be
+// merged into another DB.
+type tableDump struct {
+	Version int
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// dbDump holds every table of the DB, keyed by table name.
+type dbDump struct {
+	Tables map[string]tableDump
+}
+
+// dumpTables lists the tables that are exported, in the order they're
+// created.
+func dumpTables() []string {
+	tables := []string{imageTable}
+	for _, table := range laterTables {
+		tables = append(tables, table.name)
+	}
+	return tables
+}
+
+// tableColumns returns the names of a table's columns, except id.
+func tableColumns(db *sql.DB, table string) ([]string, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	columns := make([]string, 0, 10)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt interface{}
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		if name != "id" {
+			columns = append(columns, name)
+		}
+	}
+
+	return columns, rows.Err()
+}
+
+// dumpTable reads all the rows of a table.
+func dumpTable(db *sql.DB, table string, version int) (tableDump, error) {
+	dump := tableDump{Version: version, Rows: make([][]interface{}, 0, 100)}
+	columns, err := tableColumns(db, table)
+	if err != nil {
+		return dump, err
+	}
+	dump.Columns = columns
+
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM %s ORDER BY id;", strings.Join(columns, ", "), table))
+	if err != nil {
+		return dump, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return dump, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		dump.Rows = append(dump.Rows, values)
+	}
+
+	return dump, rows.Err()
+}
+
+// dumpDB reads every table of the DB.
+func dumpDB(db *sql.DB) (dbDump, error) {
+	dump := dbDump{Tables: make(map[string]tableDump)}
+	versions, err := getTableVersions(db)
+	if err != nil {
+		return dump, err
+	}
+
+	for _, table := range dumpTables() {
+		tdump, err := dumpTable(db, table, versions[table])
+		if err != nil {
+			return dump, fmt.Errorf("reading table %s: %v", table, err)
+		}
+		dump.Tables[table] = tdump
+	}
+
+	return dump, nil
+}
+
+// writeDumpJSON writes the whole dump as a single JSON object.
+func writeDumpJSON(w io.Writer, dump dbDump) error {
+	bytes, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(bytes))
+	return err
+}
+
+// readDumpJSON reads a dump written by writeDumpJSON.
+func readDumpJSON(r io.Reader) (dbDump, error) {
+	var dump dbDump
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	if err := decoder.Decode(&dump); err != nil {
+		return dump, err
+	}
+	return dump, nil
+}
+
+// csvNull is written in place of NULL in CSV files.  Values that begin with a
+// backslash get another one, so they can't be mistaken for it.
+const csvNull = `\N`
+
+// encodeCSVValue formats a value from the DB for a CSV file.
+func encodeCSVValue(v interface{}) string {
+	if v == nil {
+		return csvNull
+	}
+	s := fmt.Sprint(v)
+	if strings.HasPrefix(s, `\`) {
+		s = `\` + s
+	}
+	return s
+}
+
+// decodeCSVValue reverses encodeCSVValue.
+func decodeCSVValue(s string) interface{} {
+	if s == csvNull {
+		return nil
+	}
+	return strings.TrimPrefix(s, `\`)
+}
+
+// writeDumpCSV writes each table to <table>.csv in dir, with the table
+// versions in table_versions.csv.  NULLs are written as csvNull.
+func writeDumpCSV(dir string, dump dbDump) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	versions := [][]string{{"name", "version"}}
+	for _, table := range dumpTables() {
+		tdump, found := dump.Tables[table]
+		if !found {
+			continue
+		}
+		versions = append(versions, []string{table, strconv.Itoa(tdump.Version)})
+
+		records := [][]string{tdump.Columns}
+		for _, row := range tdump.Rows {
+			record := make([]string, len(row))
+			for i, v := range row {
+				record[i] = encodeCSVValue(v)
+			}
+			records = append(records, record)
+		}
+		if err := writeCSVFile(filepath.Join(dir, table+".csv"), records); err != nil {
+			return err
+		}
+	}
+
+	return writeCSVFile(filepath.Join(dir, versionTable+".csv"), versions)
+}
+
+func writeCSVFile(filename string, records [][]string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	w := csv.NewWriter(file)
+	if err := w.WriteAll(records); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func readCSVFile(filename string) ([][]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+	return csv.NewReader(file).ReadAll()
+}
+
+// readDumpCSV reads a folder written by writeDumpCSV.  Missing table files
+// are skipped.
+func readDumpCSV(dir string) (dbDump, error) {
+	dump := dbDump{Tables: make(map[string]tableDump)}
+	versionRecords, err := readCSVFile(filepath.Join(dir, versionTable+".csv"))
+	if err != nil {
+		return dump, err
+	}
+
+	versions := make(map[string]int)
+	for _, record := range versionRecords[1:] {
+		if len(record) != 2 {
+			return dump, fmt.Errorf("bad row in %s.csv: %v", versionTable, record)
+		}
+		version, err := strconv.Atoi(record[1])
+		if err != nil {
+			return dump, fmt.Errorf("bad version for table %s: %s", record[0], record[1])
+		}
+		versions[record[0]] = version
+	}
+
+	for _, table := range dumpTables() {
+		records, err := readCSVFile(filepath.Join(dir, table+".csv"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return dump, err
+		}
+		if len(records) == 0 {
+			return dump, fmt.Errorf("%s.csv has no header", table)
+		}
+
+		tdump := tableDump{Version: versions[table], Columns: records[0],
+			Rows: make([][]interface{}, 0, len(records)-1)}
+		for _, record := range records[1:] {
+			row := make([]interface{}, len(record))
+			for i, v := range record {
+				row[i] = decodeCSVValue(v)
+			}
+			tdump.Rows = append(tdump.Rows, row)
+		}
+		dump.Tables[table] = tdump
+	}
+
+	return dump, nil
+}
+
+// mergeCounts is the number of rows of a table that were added and skipped
+// as duplicates by an import.
+type mergeCounts struct {
+	Added   int
+	Skipped int
+}
+
+// mergeTable inserts the dump's rows into a table.  A row is a duplicate if
+// the table already has a row with the same values, or if inserting it would
+// break one of the table's unique constraints, in which case the existing row
+// is kept.
+func mergeTable(tx *sql.Tx, table string, tdump tableDump) (mergeCounts, error) {
+	var counts mergeCounts
+	conditions := make([]string, len(tdump.Columns))
+	placeholders := make([]string, len(tdump.Columns))
+	for i, column := range tdump.Columns {
+		conditions[i] = column + " IS ?"
+		placeholders[i] = "?"
+	}
+
+	countStmt, err := tx.Prepare(fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s;",
+		table, strings.Join(conditions, " AND ")))
+	if err != nil {
+		return counts, err
+	}
+	defer countStmt.Close()
+
+	insertStmt, err := tx.Prepare(fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s);",
+		table, strings.Join(tdump.Columns, ", "), strings.Join(placeholders, ", ")))
+	if err != nil {
+		return counts, err
+	}
+	defer insertStmt.Close()
+
+	for _, row := range tdump.Rows {
+		if len(row) != len(tdump.Columns) {
+			return counts, fmt.Errorf("row has %d values, but there are %d columns", len(row), len(tdump.Columns))
+		}
+
+		var count int
+		if err := countStmt.QueryRow(row...).Scan(&count); err != nil {
+			return counts, err
+		}
+		if count > 0 {
+			counts.Skipped++
+			continue
+		}
+
+		result, err := insertStmt.Exec(row...)
+		if err != nil {
+			return counts, err
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			counts.Skipped++
+			continue
+		}
+		counts.Added++
+	}
+
+	return counts, nil
+}
+
+// checkDump makes sure the dump's tables can be imported by this version of
+// smuggo.  Tables from older versions are accepted, since migrations only add
+// columns, and the columns missing from the dump get their defaults.
+func checkDump(db *sql.DB, dump dbDump) error {
+	latest := latestTableVersions()
+	for table, tdump := range dump.Tables {
+		version, known := latest[table]
+		if !known {
+			return fmt.Errorf("unknown table %s", table)
+		}
+		if tdump.Version > version {
+			return fmt.Errorf("table %s is version %d, but this version of smuggo only supports up to %d",
+				table, tdump.Version, version)
+		}
+
+		columns, err := tableColumns(db, table)
+		if err != nil {
+			return err
+		}
+		for _, column := range tdump.Columns {
+			if !hasColumn(columns, column) {
+				return fmt.Errorf("table %s has no column %s", table, column)
+			}
+		}
+	}
+	return nil
+}
+
+func hasColumn(columns []string, column string) bool {
+	for _, c := range columns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeDump adds the dump's rows to the DB in a single transaction, so
+// nothing is imported if any table fails.
+func mergeDump(db *sql.DB, dump dbDump) (map[string]mergeCounts, error) {
+	if err := checkDump(db, dump); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]mergeCounts)
+	for _, table := range dumpTables() {
+		tdump, found := dump.Tables[table]
+		if !found {
+			continue
+		}
+		tcounts, err := mergeTable(tx, table, tdump)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("importing table %s: %v", table, err)
+		}
+		counts[table] = tcounts
+	}
+
+	return counts, tx.Commit()
+}
+
+// dbExport writes every table to a JSON file, or to a folder of CSV files.
+// A filename of "-" writes JSON to stdout.
+func dbExport(filename string, format listFormat) {
+	if format.Template != nil || (format.Format != formatText && format.Format != formatJSON &&
+		format.Format != formatCSV) {
+		log.Println("Error: db export supports -format json or csv")
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	dump, err := dumpDB(db)
+	if err != nil {
+		log.Println("Error reading database: " + err.Error())
+		return
+	}
+
+	if format.Format == formatCSV {
+		if filename == "-" {
+			log.Println("Error: CSV export needs a folder")
+			return
+		}
+		err = writeDumpCSV(filename, dump)
+	} else if filename == "-" {
+		err = writeDumpJSON(os.Stdout, dump)
+	} else {
+		var file *os.File
+		if file, err = os.Create(filename); err == nil {
+			err = writeDumpJSON(file, dump)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}
+	if err != nil {
+		log.Println("Error exporting database: " + err.Error())
+		return
+	}
+
+	if filename != "-" {
+		for _, table := range dumpTables() {
+			fmt.Printf("Exported %d rows from %s\n", len(dump.Tables[table].Rows), table)
+		}
+	}
+}
+
+// dbImport merges a JSON file, or a folder of CSV files, written by dbExport
+// into the DB.  A filename of "-" reads JSON from stdin.
+func dbImport(filename string) {
+	var dump dbDump
+	var err error
+	if filename == "-" {
+		dump, err = readDumpJSON(os.Stdin)
+	} else if info, statErr := os.Stat(filename); statErr != nil {
+		err = statErr
+	} else if info.IsDir() {
+		dump, err = readDumpCSV(filename)
+	} else {
+		var file *os.File
+		if file, err = os.Open(filename); err == nil {
+			dump, err = readDumpJSON(file)
+			file.Close()
+		}
+	}
+	if err != nil {
+		log.Println("Error reading " + filename + ": " + err.Error())
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	counts, err := mergeDump(db, dump)
+	if err != nil {
+		log.Println("Error importing: " + err.Error())
+		return
+	}
+
+	for _, table := range dumpTables() {
+		if tcounts, found := counts[table]; found {
+			fmt.Printf("%s: added %d rows, skipped %d duplicates\n", table, tcounts.Added, tcounts.Skipped)
+		}
+	}
+}
+
+// backupConn opens a DB with the backup driver and returns its connection.
+func backupConn(filename string) (*sql.DB, *sqlite3.SQLiteConn, error) {
+	db, err := sql.Open(backupDriverName, filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	select {
+	case conn := <-backupDriverConns:
+		return db, conn, nil
+	default:
+		db.Close()
+		return nil, nil, errors.New("no connection to " + filename)
+	}
+}
+
+// backupDB copies the DB at srcFile to destFile with SQLite's online backup,
+// which allows other programs to use the DB during the backup.
+func backupDB(srcFile string, destFile string) error {
+	srcDB, srcConn, err := backupConn(srcFile)
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+
+	destDB, destConn, err := backupConn(destFile)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	backup, err := destConn.Backup("main", srcConn, "main")
+	if err != nil {
+		return err
+	}
+
+	for {
+		done, err := backup.Step(backupStepPages)
+		if err != nil {
+			backup.Finish()
+			return err
+		}
+		if done {
+			break
+		}
+	}
+
+	return backup.Finish()
+}
+
+// dbBackup copies smuggo's DB to a new file.
+func dbBackup(filename string) {
+	if _, err := os.Stat(filename); err == nil {
+		log.Println("Error: " + filename + " already exists")
+		return
+	}
+
+	dbFile := path.Join(smuggoDirFlag, "images.db")
+	if err := backupDB(dbFile, filename); err != nil {
+		log.Println("Error backing up database: " + err.Error())
+		return
+	}
+
+	fmt.Println("Backed up database to " + filename)
+}
+
+// dbVacuum rebuilds smuggo's DB to reclaim unused space.
+func dbVacuum() {
+	dbFile := path.Join(smuggoDirFlag, "images.db")
+	before, err := os.Stat(dbFile)
+	if err != nil {
+		log.Println("Error: " + err.Error())
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	if _, err := db.Exec("VACUUM;"); err != nil {
+		log.Println("Error vacuuming database: " + err.Error())
+		return
+	}
+
+	after, err := os.Stat(dbFile)
+	if err != nil {
+		log.Println("Error: " + err.Error())
+		return
+	}
+	fmt.Printf("Database size: %s -> %s\n", formatSize(before.Size()), formatSize(after.Size()))
+}
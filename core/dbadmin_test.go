@@ -0,0 +1,213 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// setUpDumpDB creates a DB with rows in several tables.
+func setUpDumpDB(t *testing.T) *sql.DB {
+	db := setUpTestDB(t)
+	createTables(db, imageTableVersion)
+
+	writeTestImageData(db, "k1", []imageJSON{{"hash-1", "a.jpg"}, {"hash-2", "b.jpg"}})
+	// NULLs and values that look like the CSV NULL must survive a round trip.
+	_, err := db.Exec(fmt.Sprintf("INSERT INTO %s (album_key, hash, filename) VALUES ('k2', NULL, ?), ('k2', '', ?);",
+		imageTable), `\N`, `\\c.jpg`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeBackedUpImage(db, "k1", "img-1", "hash-1", "/backup/a.jpg")
+	writeAlbumData(db, []albumJSON{{AlbumKey: "k1", Name: "Milk", ImageCount: 2}})
+	writeUploadAttempt(db, uploadAttempt{Started: "2026-10-01T10:00:00Z", Path: "/photos/a.jpg",
+		Size: 1234, AlbumKey: "k1", Result: uploadResultUploaded, Attempts: 1, DurationMs: 500})
+	return db
+}
+
+func TestExportImportJSON(t *testing.T) {
+	src := setUpDumpDB(t)
+	defer src.Close()
+
+	dump, err := dumpDB(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeDumpJSON(&buf, dump); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readDumpJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest := setUpTestDB(t)
+	defer dest.Close()
+	createTables(dest, imageTableVersion)
+	writeTestImageData(dest, "k1", []imageJSON{{"hash-1", "a.jpg"}})
+
+	counts, err := mergeDump(dest, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (mergeCounts{Added: 3, Skipped: 1}); counts[imageTable] != exp {
+		t.Errorf("expected: %v, actual: %v", exp, counts[imageTable])
+	}
+	if exp := (mergeCounts{Added: 1}); counts[uploadHistoryTable] != exp {
+		t.Errorf("expected: %v, actual: %v", exp, counts[uploadHistoryTable])
+	}
+
+	if actual := getUploadHistory(dest, "k1"); len(actual) != 1 || actual[0].Size != 1234 {
+		t.Errorf("unexpected upload history: %v", actual)
+	}
+	if actual := getAlbumData(dest); len(actual) != 1 || actual[0].Name != "Milk" {
+		t.Errorf("unexpected albums: %v", actual)
+	}
+
+	// Importing again only finds duplicates.
+	counts, err = mergeDump(dest, read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for table, tcounts := range counts {
+		if tcounts.Added != 0 {
+			t.Errorf("expected: nothing added to %s, actual: %d", table, tcounts.Added)
+		}
+	}
+}
+
+func TestExportImportCSV(t *testing.T) {
+	src := setUpDumpDB(t)
+	defer src.Close()
+
+	dump, err := dumpDB(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "smuggo-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := writeDumpCSV(dir, dump); err != nil {
+		t.Fatal(err)
+	}
+	read, err := readDumpCSV(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest := setUpTestDB(t)
+	defer dest.Close()
+	createTables(dest, imageTableVersion)
+
+	if _, err := mergeDump(dest, read); err != nil {
+		t.Fatal(err)
+	}
+
+	exported, err := dumpDB(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range dumpTables() {
+		if !reflect.DeepEqual(exported.Tables[table], dump.Tables[table]) {
+			t.Errorf("expected: %v, actual: %v", dump.Tables[table], exported.Tables[table])
+		}
+	}
+}
+
+func TestImportChecksVersions(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+	createTables(db, imageTableVersion)
+
+	dump := dbDump{Tables: map[string]tableDump{
+		imageTable: {Version: imageTableVersion + 1, Columns: []string{"album_key"}},
+	}}
+	if _, err := mergeDump(db, dump); err == nil {
+		t.Error("expected error for newer table version")
+	}
+
+	dump.Tables[imageTable] = tableDump{Version: imageTableVersion, Columns: []string{"color"}}
+	if _, err := mergeDump(db, dump); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestImportOlderVersion(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+	createTables(db, imageTableVersion)
+
+	// Version 2 of the image table has no image_key column.
+	dump := dbDump{Tables: map[string]tableDump{
+		imageTable: {Version: 2, Columns: []string{"album_key", "hash", "filename"},
+			Rows: [][]interface{}{{"album-1", "hash-1", "milk.jpg"}}},
+	}}
+	counts, err := mergeDump(db, dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[imageTable].Added != 1 {
+		t.Errorf("expected: 1 row added, actual: %d", counts[imageTable].Added)
+	}
+
+	expected := []imageRow{{ID: 1, ImageKey: "", ArchivedMD5: "hash-1", FileName: "milk.jpg"}}
+	if actual := getImageRows(db, "album-1"); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestBackupDB(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smuggo-backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srcFile := filepath.Join(dir, "images.db")
+	src, err := sql.Open("sqlite3", srcFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	createTables(src, imageTableVersion)
+	writeTestImageData(src, "k1", []imageJSON{{"hash-1", "a.jpg"}})
+
+	destFile := filepath.Join(dir, "copy.db")
+	if err := backupDB(srcFile, destFile); err != nil {
+		t.Fatal(err)
+	}
+
+	dest, err := sql.Open("sqlite3", destFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dest.Close()
+	if actual := getAlbumImageData(dest, "k1"); len(actual) != 1 || actual[0].ArchivedMD5 != "hash-1" {
+		t.Errorf("unexpected images in backup: %v", actual)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// Fields requested for images that may be downloaded.
+const imageDownloadFilter = imageHashFilter + ",ArchivedSize,ArchivedUri,Uri"
+
+// downloadImages saves the archived originals of the given images to destDir,
+// numParallel at a time.  Files that already exist with a matching hash are
+// skipped, and existing files with different contents are never overwritten.
+// If renameExisting is true, an image whose filename is taken by a different
+// file is saved under a name with its image key added; otherwise it isn't
+// downloaded.  If saved is not nil, it is called for each image that is in
+// destDir afterwards and may be called from multiple goroutines.  Returns the
+// number of images that could not be downloaded.
+func downloadImages(client *http.Client, userToken *oauth.Credentials,
+	images []albumImageJSON, destDir string, numParallel int, renameExisting bool,
+	saved func(img albumImageJSON, destPath string)) int {
+
+	var failed = 0
+	var failedMutex sync.Mutex
+	waitGrp := sync.WaitGroup{}
+	semaph := make(chan int, numParallel)
+
+	// Hashes of the files claimed by this batch, keyed by destination path.
+	claimed := make(map[string]string, len(images))
+
+	for _, img := range images {
+		destPath := filepath.Join(destDir, filepath.Base(img.FileName))
+		if _, err := os.Stat(destPath); err == nil && renameExisting {
+			if hash, _, err := calcMD5(destPath); err != nil || hash != img.ArchivedMD5 {
+				destPath = uniqueImagePath(destPath, img.ImageKey)
+			}
+		}
+		if hash, ok := claimed[destPath]; ok {
+			if hash == img.ArchivedMD5 {
+				continue
+			}
+			destPath = uniqueImagePath(destPath, img.ImageKey)
+		}
+		claimed[destPath] = img.ArchivedMD5
+
+		if _, err := os.Stat(destPath); err == nil {
+			hash, _, err := calcMD5(destPath)
+			if err == nil && hash == img.ArchivedMD5 {
+				fmt.Println("Already have " + destPath)
+				if saved != nil {
+					saved(img, destPath)
+				}
+				continue
+			}
+			fmt.Printf("Not downloading %s, a different file already exists at %s\n",
+				img.FileName, destPath)
+			failed++
+			continue
+		}
+
+		waitGrp.Add(1)
+		semaph <- 1
+		go func(img albumImageJSON, destPath string) {
+			defer waitGrp.Done()
+			fmt.Println("Downloading " + destPath)
+			err := downloadImage(client, userToken, img, destPath, retriesFlag+1)
+			if err != nil {
+				log.Println("Error downloading: " + err.Error())
+				failedMutex.Lock()
+				failed++
+				failedMutex.Unlock()
+			} else if saved != nil {
+				saved(img, destPath)
+			}
+			<-semaph
+		}(img, destPath)
+	}
+
+	waitGrp.Wait()
+	return failed
+}
+
+// uniqueImagePath adds the image key to a filename so images in the same
+// album that share a filename don't overwrite each other.
+func uniqueImagePath(destPath string, imageKey string) string {
+	ext := filepath.Ext(destPath)
+	return strings.TrimSuffix(destPath, ext) + "_" + imageKey + ext
+}
+
+// downloadAlbum saves the archived originals of every image in an album to
+// destDir.
+func downloadAlbum(albumKey string, destDir string) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	if err := os.MkdirAll(destDir, os.ModeDir|0755); err != nil {
+		log.Println("Error creating " + destDir + ": " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, albumKey, imageDownloadFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	failed := downloadImages(&client, userToken, images, destDir, parallelFlag, false, nil)
+	fmt.Printf("%d of %d images in %s.\n", len(images)-failed, len(images), destDir)
+}
+
+// downloadImage saves an image's archived original to destPath.  The image is
+// written to a temporary file first and only renamed to destPath once its MD5
+// hash matches the one reported by SmugMug.
+func downloadImage(client *http.Client, userToken *oauth.Credentials,
+	img albumImageJSON, destPath string, tries uint) error {
+
+	if img.ArchivedURI == "" {
+		return fmt.Errorf("no archived original for %s", img.FileName)
+	}
+
+	var err error
+	var tryCount uint
+	for tryCount = 0; tryCount < tries; tryCount++ {
+		err = fetchArchive(client, userToken, img, destPath)
+		if err == nil {
+			return nil
+		}
+		log.Println("Error fetching " + img.FileName + ": " + err.Error())
+	}
+
+	return fmt.Errorf("unable to download %s after %d attempts: %v", img.FileName, tries, err)
+}
+
+// fetchArchive makes a single attempt at downloading an image's archived
+// original.
+func fetchArchive(client *http.Client, userToken *oauth.Credentials,
+	img albumImageJSON, destPath string) error {
+
+	resp, err := oauthClient.Get(client, userToken, img.ArchivedURI, nil)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(destPath), "."+filepath.Base(destPath)+".")
+	if err != nil {
+		return err
+	}
+
+	hash := md5.New()
+	_, err = io.Copy(io.MultiWriter(tmpFile, hash), resp.Body)
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	md5Sum := fmt.Sprintf("%x", hash.Sum(nil))
+	if img.ArchivedMD5 != "" && md5Sum != img.ArchivedMD5 {
+		os.Remove(tmpFile.Name())
+		return fmt.Errorf("MD5 mismatch, expected %s, got %s", img.ArchivedMD5, md5Sum)
+	}
+
+	if err := os.Chmod(tmpFile.Name(), 0644); err != nil {
+		os.Remove(tmpFile.Name())
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), destPath)
+}
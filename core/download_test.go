@@ -0,0 +1,146 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+var fakeImageContents = []byte("not really a jpeg")
+
+func serveFakeImage(resp http.ResponseWriter, req *http.Request) {
+	resp.WriteHeader(http.StatusOK)
+	resp.Write(fakeImageContents)
+}
+
+func TestDownloadImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(serveFakeImage))
+	defer server.Close()
+
+	destPath := path.Join(testDir, "downloaded.jpg")
+	defer os.Remove(destPath)
+
+	img := albumImageJSON{
+		FileName:    "downloaded.jpg",
+		ArchivedMD5: fmt.Sprintf("%x", md5.Sum(fakeImageContents)),
+		ArchivedURI: server.URL,
+	}
+
+	var client = http.Client{}
+	err := downloadImage(&client, &oauth.Credentials{}, img, destPath, 1)
+	if err != nil {
+		t.Error("Error downloading: ", err)
+	}
+
+	contents, err := ioutil.ReadFile(destPath)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(contents) != string(fakeImageContents) {
+		t.Errorf("expected: %s, actual: %s", fakeImageContents, contents)
+	}
+}
+
+func TestDownloadImageHashMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(serveFakeImage))
+	defer server.Close()
+
+	destPath := path.Join(testDir, "mismatch.jpg")
+	defer os.Remove(destPath)
+
+	img := albumImageJSON{
+		FileName:    "mismatch.jpg",
+		ArchivedMD5: "not-the-hash",
+		ArchivedURI: server.URL,
+	}
+
+	var client = http.Client{}
+	err := downloadImage(&client, &oauth.Credentials{}, img, destPath, 2)
+	if err == nil {
+		t.Error("Expected error from downloadImage()")
+	}
+
+	if _, err := os.Stat(destPath); err == nil {
+		t.Error("Image with wrong hash should not be saved")
+	}
+}
+
+func TestUniqueImagePath(t *testing.T) {
+	expected := path.Join("photos", "milk_Xk3f9.jpg")
+	actual := uniqueImagePath(path.Join("photos", "milk.jpg"), "Xk3f9")
+
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestDownloadImagesRenameExisting(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		resp.WriteHeader(http.StatusOK)
+		resp.Write([]byte(req.URL.Path))
+	}))
+	defer server.Close()
+
+	destDir, err := ioutil.TempDir("", "smuggo-download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(destDir)
+
+	first := albumImageJSON{ImageKey: "key-1", FileName: "milk.jpg",
+		ArchivedMD5: fmt.Sprintf("%x", md5.Sum([]byte("/first"))), ArchivedURI: server.URL + "/first"}
+	second := albumImageJSON{ImageKey: "key-2", FileName: "milk.jpg",
+		ArchivedMD5: fmt.Sprintf("%x", md5.Sum([]byte("/second"))), ArchivedURI: server.URL + "/second"}
+
+	var client = http.Client{}
+	savedPaths := make(map[string]string)
+	saved := func(img albumImageJSON, destPath string) {
+		savedPaths[img.ImageKey] = destPath
+	}
+
+	// A later run finds a different image with the same filename.
+	if failed := downloadImages(&client, &oauth.Credentials{}, []albumImageJSON{first}, destDir, 1, true, saved); failed != 0 {
+		t.Errorf("expected: 0 failed, actual: %d", failed)
+	}
+	if failed := downloadImages(&client, &oauth.Credentials{}, []albumImageJSON{second}, destDir, 1, false, nil); failed != 1 {
+		t.Errorf("expected existing file to be kept, actual: %d failed", failed)
+	}
+	if failed := downloadImages(&client, &oauth.Credentials{}, []albumImageJSON{second}, destDir, 1, true, saved); failed != 0 {
+		t.Errorf("expected: 0 failed, actual: %d", failed)
+	}
+
+	expected := map[string]string{
+		"key-1": path.Join(destDir, "milk.jpg"),
+		"key-2": path.Join(destDir, "milk_key-2.jpg"),
+	}
+	if !reflect.DeepEqual(expected, savedPaths) {
+		t.Errorf("expected: %v, actual: %v", expected, savedPaths)
+	}
+
+	// Running again finds both images already saved.
+	if failed := downloadImages(&client, &oauth.Credentials{}, []albumImageJSON{first, second}, destDir, 1, true, saved); failed != 0 {
+		t.Errorf("expected: 0 failed, actual: %d", failed)
+	}
+}
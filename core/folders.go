@@ -0,0 +1,188 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+const (
+	apiFolder        = apiRoot + "/api/v2/folder/user"
+	apiMultiFolders  = "!folders"
+	apiFolderAlbums  = "!albums"
+	privacyPublic    = "Public"
+	privacyUnlisted  = "Unlisted"
+	privacyPrivate   = "Private"
+	securityPassword = "Password"
+)
+
+type folderJSON struct {
+	Name    string
+	URLName string
+	URLPath string
+	URI     string
+	NodeID  string
+}
+
+type folderEndpointJSON struct {
+	Folder folderJSON
+	Album  albumJSON
+}
+
+// Top level response for a single folder or a newly created album.
+type folderResponseJSON struct {
+	Response folderEndpointJSON
+}
+
+// albumFolderPath returns the path of the folder that holds the album, such
+// as Clients/2026, using the folder portion of the album's URL path.  Albums
+// at the top level have an empty folder path.  Albums read from the DB have
+// no URL path, so their saved folder path is used.
+func albumFolderPath(album albumJSON) string {
+	if album.URLPath == "" {
+		return album.FolderPath
+	}
+	return strings.Trim(path.Dir(album.URLPath), "/.")
+}
+
+// urlName converts a name into a form SmugMug accepts as a URL name: letters,
+// numbers and dashes, beginning with a capital letter or number.
+func urlName(name string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			dash = true
+			continue
+		}
+		if dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		dash = false
+		sb.WriteRune(r)
+	}
+
+	converted := sb.String()
+	if converted == "" {
+		return ""
+	}
+	return strings.ToUpper(converted[:1]) + converted[1:]
+}
+
+// splitFolderPath breaks a folder path like Clients/2026 into its folder
+// names, ignoring empty names.
+func splitFolderPath(folderPath string) []string {
+	names := make([]string, 0, 5)
+	for _, name := range strings.Split(folderPath, "/") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// normalizePrivacy checks that privacy names a SmugMug privacy setting,
+// ignoring case.
+func normalizePrivacy(privacy string) (string, error) {
+	for _, setting := range []string{privacyPublic, privacyUnlisted, privacyPrivate} {
+		if strings.EqualFold(privacy, setting) {
+			return setting, nil
+		}
+	}
+	return "", fmt.Errorf("privacy must be %s, %s or %s", privacyPublic, privacyUnlisted, privacyPrivate)
+}
+
+// folderPathURI builds the URI of the folder at a URL path like
+// /Clients/2026, as returned by SmugMug.
+func folderPathURI(nickname string, urlPath string) string {
+	uri := apiFolder + "/" + nickname
+	if urlPath = strings.Trim(urlPath, "/"); urlPath != "" {
+		uri += "/" + urlPath
+	}
+	return uri
+}
+
+// findChildFolder returns the folder among a node's children with the given
+// name, ignoring case.
+func findChildFolder(children []nodeJSON, name string) (nodeJSON, bool) {
+	for _, child := range children {
+		if child.Type == nodeTypeFolder && strings.EqualFold(strings.TrimSpace(child.Name), name) {
+			return child, true
+		}
+	}
+	return nodeJSON{}, false
+}
+
+// ensureFolder returns the node of the folder at folderPath, with its node ID
+// and URL path.  Folders are looked up by name, since their URL names may
+// differ from the ones smuggo would derive.  If create is true, missing
+// folders along the path are created with the given privacy (an empty
+// privacy uses SmugMug's default).
+func ensureFolder(client *http.Client, userToken *oauth.Credentials,
+	nickname string, folderPath string, create bool, privacy string) (nodeJSON, error) {
+
+	var rootJSON folderResponseJSON
+	if err := apiGet(client, userToken, folderPathURI(nickname, ""), nil, &rootJSON); err != nil {
+		return nodeJSON{}, err
+	}
+	folder := folderNode(rootJSON.Response.Folder)
+
+	names := splitFolderPath(folderPath)
+	for i, name := range names {
+		children, err := fetchNodeChildren(client, userToken, folder.NodeID)
+		if err != nil {
+			return nodeJSON{}, err
+		}
+		if child, found := findChildFolder(children, name); found {
+			folder = child
+			continue
+		}
+		if !create {
+			return nodeJSON{}, fmt.Errorf("folder %s not found", strings.Join(names[:i+1], "/"))
+		}
+
+		body := map[string]string{
+			"Name":    name,
+			"UrlName": urlName(name),
+		}
+		if privacy != "" {
+			body["Privacy"] = privacy
+		}
+		var respJSON folderResponseJSON
+		parentURI := folderPathURI(nickname, folder.URLPath)
+		if err := apiSend(client, userToken, "POST", parentURI+apiMultiFolders, body, &respJSON); err != nil {
+			return nodeJSON{}, fmt.Errorf("creating folder %s: %v", strings.Join(names[:i+1], "/"), err)
+		}
+		fmt.Println("Created folder " + strings.Join(names[:i+1], "/"))
+		folder = folderNode(respJSON.Response.Folder)
+	}
+
+	if folder.NodeID == "" {
+		return nodeJSON{}, fmt.Errorf("no node found for folder %s", folderPath)
+	}
+	return folder, nil
+}
+
+// folderNode converts a folder to the node that refers to it.
+func folderNode(folder folderJSON) nodeJSON {
+	return nodeJSON{NodeID: folder.NodeID, Name: folder.Name, Type: nodeTypeFolder, URLPath: folder.URLPath}
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestURLName(t *testing.T) {
+	tests := map[string]string{
+		"Smith Wedding":      "Smith-Wedding",
+		"smith & jones 2026": "Smith-jones-2026",
+		"  --Staging--  ":    "Staging",
+		"2026":               "2026",
+		"Café":               "Caf",
+		"!!!":                "",
+	}
+
+	for name, expected := range tests {
+		actual := urlName(name)
+		if expected != actual {
+			t.Errorf("name: %s, expected: %s, actual: %s", name, expected, actual)
+		}
+	}
+}
+
+func TestSplitFolderPath(t *testing.T) {
+	expected := []string{"Clients", "2026"}
+	actual := splitFolderPath("/Clients//2026/")
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestFolderPathURI(t *testing.T) {
+	expected := apiFolder + "/nick/Clients/Smith-Wedding-1"
+	actual := folderPathURI("nick", "/Clients/Smith-Wedding-1")
+
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+
+	if root := folderPathURI("nick", ""); root != apiFolder+"/nick" {
+		t.Errorf("expected: %s, actual: %s", apiFolder+"/nick", root)
+	}
+}
+
+func TestFindChildFolder(t *testing.T) {
+	children := []nodeJSON{
+		{NodeID: "a1", Name: "Smith & Jones", Type: "Album"},
+		{NodeID: "f1", Name: "Smith & Jones", Type: nodeTypeFolder, URLPath: "/Clients/Smith-Jones-2019"},
+	}
+
+	child, found := findChildFolder(children, "smith & jones")
+	if !found || child.NodeID != "f1" {
+		t.Errorf("expected: %s, actual: %s", "f1", child.NodeID)
+	}
+
+	if _, found := findChildFolder(children, "Smith-Jones"); found {
+		t.Errorf("expected no folder named Smith-Jones")
+	}
+}
+
+func TestAlbumFolderPath(t *testing.T) {
+	tests := map[string]string{
+		"/Clients/2026/Smith-Wedding": "Clients/2026",
+		"/Staging":                    "",
+		"":                            "",
+	}
+
+	for urlPath, expected := range tests {
+		actual := albumFolderPath(albumJSON{URLPath: urlPath})
+		if expected != actual {
+			t.Errorf("URL path: %s, expected: %s, actual: %s", urlPath, expected, actual)
+		}
+	}
+}
+
+func TestNormalizePrivacy(t *testing.T) {
+	if privacy, err := normalizePrivacy("unlisted"); err != nil || privacy != privacyUnlisted {
+		t.Errorf("expected: %s, actual: %s, %v", privacyUnlisted, privacy, err)
+	}
+
+	if _, err := normalizePrivacy("secret"); err == nil {
+		t.Error("Expected error for unknown privacy")
+	}
+}
+
+func TestFolderNode(t *testing.T) {
+	expected := nodeJSON{NodeID: "n1", Name: "Smith & Jones", Type: nodeTypeFolder, URLPath: "/Clients/Smith-Jones-2019"}
+	actual := folderNode(folderJSON{Name: "Smith & Jones", URLName: "Smith-Jones-2019",
+		URLPath: "/Clients/Smith-Jones-2019", NodeID: "n1"})
+
+	if expected != actual {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
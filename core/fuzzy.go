@@ -0,0 +1,173 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// How much a match in each album field counts towards an album's rank.
+const (
+	nameWeight        = 3.0
+	folderPathWeight  = 2.0
+	descriptionWeight = 1.0
+)
+
+// rankedAlbum is an album that matched a search, along with how well it
+// matched.
+type rankedAlbum struct {
+	Album   albumJSON
+	Matched int     // Number of search terms that matched.
+	Score   float64 // Sum of each term's best weighted match.
+}
+
+// tokenize lowercases text and splits it into words made of letters and
+// numbers.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// editDistance returns the number of single letter insertions, deletions,
+// substitutions and swaps of neighboring letters needed to turn one word into
+// the other.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prevPrev := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(minInt(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = minInt(cur[j], prevPrev[j-2]+1)
+			}
+		}
+		prevPrev, prev, cur = prev, cur, prevPrev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// termScore rates how well a search term matches a word, from 0 (no match) to
+// 1 (exact match).  Short terms allow one typo and longer terms allow two.
+func termScore(term string, word string) float64 {
+	termLen := len([]rune(term))
+	switch {
+	case term == word:
+		return 1.0
+	case strings.HasPrefix(word, term):
+		return 0.9
+	case termLen > 2 && strings.Contains(word, term):
+		return 0.8
+	}
+
+	if termLen < 3 {
+		return 0
+	}
+	allowed := 1
+	if termLen > 5 {
+		allowed = 2
+	}
+
+	// Also allow typos in a prefix of the word, so partially typed words
+	// still match.
+	dist := editDistance(term, word)
+	if runes := []rune(word); len(runes) > termLen {
+		dist = minInt(dist, editDistance(term, string(runes[:termLen])))
+	}
+	if dist > allowed {
+		return 0
+	}
+	return 0.7 - 0.1*float64(dist)
+}
+
+// bestScore finds the best match for term among the words.
+func bestScore(term string, words []string) float64 {
+	var best float64
+	for _, word := range words {
+		if score := termScore(term, word); score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// rankAlbums scores each album against the search terms and returns the
+// albums that matched at least one term, best matches first.  Albums that
+// match more terms always rank above albums that match fewer.
+func rankAlbums(albums []albumJSON, terms []string) []rankedAlbum {
+	queryTerms := make([]string, 0, len(terms))
+	for _, term := range terms {
+		queryTerms = append(queryTerms, tokenize(term)...)
+	}
+
+	ranked := make([]rankedAlbum, 0, len(albums))
+	for _, album := range albums {
+		name := tokenize(album.Name)
+		folderPath := tokenize(albumFolderPath(album))
+		description := tokenize(album.Description)
+
+		r := rankedAlbum{Album: album}
+		for _, term := range queryTerms {
+			score := nameWeight * bestScore(term, name)
+			if s := folderPathWeight * bestScore(term, folderPath); s > score {
+				score = s
+			}
+			if s := descriptionWeight * bestScore(term, description); s > score {
+				score = s
+			}
+			if score > 0 {
+				r.Matched++
+				r.Score += score
+			}
+		}
+
+		if r.Matched > 0 {
+			ranked = append(ranked, r)
+		}
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Matched != ranked[j].Matched {
+			return ranked[i].Matched > ranked[j].Matched
+		}
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].Album.Name < ranked[j].Album.Name
+	})
+
+	return ranked
+}
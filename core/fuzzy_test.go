@@ -0,0 +1,85 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"wedding", "wedding", 0},
+		{"weding", "wedding", 1},
+		{"kitten", "sitting", 3},
+		{"smiht", "smith", 1},
+		{"", "abc", 3},
+	}
+
+	for _, test := range tests {
+		actual := editDistance(test.a, test.b)
+		if test.expected != actual {
+			t.Errorf("%s -> %s, expected: %d, actual: %d", test.a, test.b, test.expected, actual)
+		}
+	}
+}
+
+func TestTermScore(t *testing.T) {
+	if termScore("wedding", "wedding") != 1.0 {
+		t.Errorf("expected exact match to score 1")
+	}
+	if termScore("wed", "wedding") <= termScore("weding", "wedding") {
+		t.Errorf("expected prefix to score higher than a typo")
+	}
+	if termScore("weding", "wedding") == 0 {
+		t.Errorf("expected typo to match")
+	}
+	if termScore("wdding", "smith") != 0 {
+		t.Errorf("expected unrelated word not to match")
+	}
+	if termScore("ab", "xy") != 0 {
+		t.Errorf("expected short term without a prefix match not to match")
+	}
+	if termScore("é", "café") != 0 {
+		t.Errorf("expected one letter term not to match inside a word")
+	}
+}
+
+func TestRankAlbums(t *testing.T) {
+	albums := []albumJSON{
+		{AlbumKey: "k1", Name: "Family Reunion"},
+		{AlbumKey: "k2", Name: "Smith Wedding", FolderPath: "Clients/2026"},
+		{AlbumKey: "k3", Name: "Jones", Description: "Wedding reception"},
+		{AlbumKey: "k4", Name: "Portraits", FolderPath: "Clients/Smith"},
+	}
+
+	ranked := rankAlbums(albums, []string{"smiht", "weding"})
+	keys := make([]string, 0, len(ranked))
+	for _, r := range ranked {
+		keys = append(keys, r.Album.AlbumKey)
+	}
+
+	expected := []string{"k2", "k4", "k3"}
+	if !reflect.DeepEqual(expected, keys) {
+		t.Errorf("expected: %s, actual: %s", expected, keys)
+	}
+
+	if ranked[0].Matched != 2 {
+		t.Errorf("expected: 2 terms matched, actual: %d", ranked[0].Matched)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Results of an upload attempt.
+const (
+	uploadResultUploaded  = "uploaded"
+	uploadResultDuplicate = "duplicate"
+	uploadResultFailed    = "failed"
+)
+
+// uploadAttempt is one call to upload a file, including its retries, as
+// recorded in the upload history.
+type uploadAttempt struct {
+	Started    string // RFC 3339, in UTC.
+	Path       string // Absolute path of the local file.
+	Size       int64
+	AlbumKey   string
+	Result     string
+	Attempts   int // Number of times the file was sent.
+	DurationMs int64
+	ImageURI   string // Image created by a successful upload.
+	Error      string
+}
+
+// historyFilter picks upload attempts to show.  Fields that are empty or zero
+// pick every attempt.
+type historyFilter struct {
+	Name   string // Filename pattern, as used by path.Match.
+	Result string
+	Before time.Time
+	After  time.Time
+}
+
+// newHistoryFilter builds a filter from the -name, -status, -before and
+// -after flags.
+func newHistoryFilter(name string, status string, before string, after string) (historyFilter, error) {
+	filter := historyFilter{Name: name, Result: strings.ToLower(status)}
+
+	if name != "" {
+		if _, err := path.Match(name, ""); err != nil {
+			return filter, fmt.Errorf("bad -name pattern %s: %v", name, err)
+		}
+	}
+
+	switch filter.Result {
+	case "", uploadResultUploaded, uploadResultDuplicate, uploadResultFailed:
+	default:
+		return filter, fmt.Errorf("-status must be %s, %s or %s",
+			uploadResultUploaded, uploadResultDuplicate, uploadResultFailed)
+	}
+
+	var err error
+	if before != "" {
+		if filter.Before, err = parseDate(before); err != nil {
+			return filter, err
+		}
+	}
+	if after != "" {
+		if filter.After, err = parseDate(after); err != nil {
+			return filter, err
+		}
+	}
+
+	return filter, nil
+}
+
+// matches returns true if the filter picks the attempt.  A name pattern
+// without a slash matches the base of the filename, otherwise the full path.
+func (f historyFilter) matches(attempt uploadAttempt) bool {
+	if f.Name != "" {
+		name := filepath.ToSlash(attempt.Path)
+		if !strings.Contains(f.Name, "/") {
+			name = path.Base(name)
+		}
+		if matched, _ := path.Match(f.Name, name); !matched {
+			return false
+		}
+	}
+
+	if f.Result != "" && f.Result != attempt.Result {
+		return false
+	}
+
+	if !f.Before.IsZero() || !f.After.IsZero() {
+		started, err := time.Parse(time.RFC3339, attempt.Started)
+		if err != nil {
+			return false
+		}
+		if !f.Before.IsZero() && !started.Before(f.Before) {
+			return false
+		}
+		if !f.After.IsZero() && started.Before(f.After) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterHistory returns the attempts picked by the filter, keeping only the
+// latest limit attempts if limit is greater than 0.
+func filterHistory(attempts []uploadAttempt, filter historyFilter, limit int) []uploadAttempt {
+	picked := make([]uploadAttempt, 0, len(attempts))
+	for _, attempt := range attempts {
+		if filter.matches(attempt) {
+			picked = append(picked, attempt)
+		}
+	}
+
+	if limit > 0 && len(picked) > limit {
+		picked = picked[len(picked)-limit:]
+	}
+	return picked
+}
+
+// historyLine formats an attempt as a line of tab separated columns for the
+// text format.
+func historyLine(attempt uploadAttempt) string {
+	started := attempt.Started
+	if t, err := time.Parse(time.RFC3339, started); err == nil {
+		started = t.Local().Format("2006-01-02 15:04:05")
+	}
+
+	detail := attempt.ImageURI
+	if attempt.Error != "" {
+		detail = attempt.Error
+	}
+
+	return strings.Join([]string{
+		started,
+		attempt.Result,
+		attempt.AlbumKey,
+		attempt.Path,
+		formatSize(attempt.Size),
+		fmt.Sprintf("%d tries", attempt.Attempts),
+		(time.Duration(attempt.DurationMs) * time.Millisecond).String(),
+		detail,
+	}, "\t")
+}
+
+// history prints the recorded upload attempts for an album, or for every
+// album if albumKey is empty, oldest first.
+func history(albumKey string, filter historyFilter, limit int, format listFormat) {
+	db := openDB()
+	defer db.Close()
+
+	attempts := filterHistory(getUploadHistory(db, albumKey), filter, limit)
+
+	var err error
+	if format.structured() {
+		err = format.writeList(os.Stdout, attempts, nil)
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		err = format.writeList(tw, attempts, func(i int) string {
+			return historyLine(attempts[i])
+		})
+		if err == nil {
+			err = tw.Flush()
+		}
+	}
+	if err != nil {
+		log.Println("Error printing upload history: " + err.Error())
+	}
+}
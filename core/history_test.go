@@ -0,0 +1,152 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+var testHistory = []uploadAttempt{
+	{Started: "2026-10-01T10:00:00Z", Path: "/photos/a/milk.jpg", AlbumKey: "k1", Result: uploadResultUploaded},
+	{Started: "2026-10-02T10:00:00Z", Path: "/photos/b/eggs.png", AlbumKey: "k1", Result: uploadResultFailed},
+	{Started: "2026-10-03T10:00:00Z", Path: "/photos/a/milk.jpg", AlbumKey: "k2", Result: uploadResultDuplicate},
+}
+
+func historyPaths(attempts []uploadAttempt) []string {
+	paths := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		paths = append(paths, a.Started[:10]+" "+a.Path)
+	}
+	return paths
+}
+
+func TestFilterHistory(t *testing.T) {
+	cases := []struct {
+		name   string
+		status string
+		before string
+		after  string
+		limit  int
+		exp    []string
+	}{
+		{"", "", "", "", 0, []string{
+			"2026-10-01 /photos/a/milk.jpg", "2026-10-02 /photos/b/eggs.png", "2026-10-03 /photos/a/milk.jpg"}},
+		{"", "", "", "", 2, []string{"2026-10-02 /photos/b/eggs.png", "2026-10-03 /photos/a/milk.jpg"}},
+		{"milk.*", "", "", "", 0, []string{"2026-10-01 /photos/a/milk.jpg", "2026-10-03 /photos/a/milk.jpg"}},
+		{"/photos/b/*", "", "", "", 0, []string{"2026-10-02 /photos/b/eggs.png"}},
+		{"", "FAILED", "", "", 0, []string{"2026-10-02 /photos/b/eggs.png"}},
+		{"", "", "2026-10-02T10:00:00Z", "", 0, []string{"2026-10-01 /photos/a/milk.jpg"}},
+		{"", "", "", "2026-10-02T10:00:00Z", 0, []string{
+			"2026-10-02 /photos/b/eggs.png", "2026-10-03 /photos/a/milk.jpg"}},
+	}
+
+	for _, c := range cases {
+		filter, err := newHistoryFilter(c.name, c.status, c.before, c.after)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := historyPaths(filterHistory(testHistory, filter, c.limit))
+		if !reflect.DeepEqual(actual, c.exp) {
+			t.Errorf("expected: %v, actual: %v", c.exp, actual)
+		}
+	}
+}
+
+func TestNewHistoryFilterErrors(t *testing.T) {
+	if _, err := newHistoryFilter("", "lost", "", ""); err == nil {
+		t.Error("expected error for bad status")
+	}
+	if _, err := newHistoryFilter("[", "", "", ""); err == nil {
+		t.Error("expected error for bad pattern")
+	}
+	if _, err := newHistoryFilter("", "", "yesterday", ""); err == nil {
+		t.Error("expected error for bad date")
+	}
+}
+
+func TestUploadHistoryTable(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	for _, a := range testHistory {
+		writeUploadAttempt(db, a)
+	}
+
+	if actual := getUploadHistory(db, ""); !reflect.DeepEqual(actual, testHistory) {
+		t.Errorf("expected: %v, actual: %v", testHistory, actual)
+	}
+	if actual := getUploadHistory(db, "k2"); !reflect.DeepEqual(actual, testHistory[2:]) {
+		t.Errorf("expected: %v, actual: %v", testHistory[2:], actual)
+	}
+}
+
+func TestPostImageRecordsHistory(t *testing.T) {
+	handler := CountHandler{}
+	server := httptest.NewServer(http.HandlerFunc(handler.FailResponse))
+	defer server.Close()
+
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	f, err := ioutil.TempFile("", "smuggo-history-*.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not really a jpeg")
+	f.Close()
+	defer os.Remove(f.Name())
+
+	filename := f.Name()
+	var client = http.Client{}
+	if err := postImage(&client, server.URL, &oauth.Credentials{}, db, true, "foo", filename, 2); err == nil {
+		t.Error("expected error from postImage()")
+	}
+
+	hash, size, err := calcMD5(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestImageData(db, "foo", []imageJSON{{hash, filename}})
+	if err := postImage(&client, server.URL, &oauth.Credentials{}, db, false, "foo", filename, 2); err != nil {
+		t.Error(err)
+	}
+
+	attempts := getUploadHistory(db, "foo")
+	if len(attempts) != 2 {
+		t.Fatalf("expected: 2 attempts, actual: %d", len(attempts))
+	}
+
+	absPath, _ := filepath.Abs(filename)
+	failed := attempts[0]
+	if failed.Result != uploadResultFailed || failed.Attempts != 2 || failed.Error == "" ||
+		failed.Size != size || failed.Path != absPath {
+		t.Errorf("unexpected failed attempt: %+v", failed)
+	}
+	if dupe := attempts[1]; dupe.Result != uploadResultDuplicate || dupe.Attempts != 0 {
+		t.Errorf("unexpected duplicate attempt: %+v", dupe)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// How long an album's rows in the image table may go without fetching every
+// image.  In between, only new images are fetched, so images replaced or
+// deleted on SmugMug may be missed until then.
+const fullImageRefreshAge = 7 * 24 * time.Hour
+
+// Fields requested when refreshing the image table.
+const imageSyncFilter = imageHashFilter + ",DateTimeUploaded"
+
+// Fields requested to see whether an album's images changed.
+const albumImagesStateFilter = "AlbumKey,ImageCount,ImagesLastUpdated"
+
+// diffImageRows finds the changes that make an album's rows match its images.
+// Rows are matched to images by image key, and rows whose hash, filename or
+// upload time differ from their image are replaced.  If partial is true,
+// images holds only some of the album's images, such as the newest, so rows
+// without a matching image are kept.  Otherwise, they are deleted, along
+// with rows that have no image key.
+func diffImageRows(rows []imageRow, images []albumImageJSON, partial bool) imageRowDiff {
+	byKey := make(map[string]albumImageJSON, len(images))
+	for _, img := range images {
+		byKey[img.ImageKey] = img
+	}
+
+	var diff imageRowDiff
+	kept := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		img, found := byKey[row.ImageKey]
+		switch {
+		case row.ImageKey != "" && found && !kept[row.ImageKey] &&
+			img.ArchivedMD5 == row.ArchivedMD5 && img.FileName == row.FileName &&
+			img.DateTimeUploaded == row.Uploaded:
+			kept[row.ImageKey] = true
+		case partial && !found:
+		default:
+			diff.Deletes = append(diff.Deletes, row.ID)
+		}
+	}
+
+	// Pages may overlap if the album changes while they're fetched, so an
+	// image is only inserted once.
+	for _, img := range images {
+		if !kept[img.ImageKey] {
+			diff.Inserts = append(diff.Inserts, img)
+			kept[img.ImageKey] = true
+		}
+	}
+
+	return diff
+}
+
+// newestUploaded returns the latest upload time of the images, or newest if
+// it's later.
+func newestUploaded(newest string, images []albumImageJSON) string {
+	newestTime, _ := time.Parse(time.RFC3339, newest)
+	for _, img := range images {
+		uploaded, err := time.Parse(time.RFC3339, img.DateTimeUploaded)
+		if err == nil && uploaded.After(newestTime) {
+			newest = img.DateTimeUploaded
+			newestTime = uploaded
+		}
+	}
+	return newest
+}
+
+// fetchNewAlbumImages gets the images uploaded to an album after since,
+// newest first.  Pages are requested until one has an image uploaded at or
+// before since, so a few older images may be included.
+func fetchNewAlbumImages(client *http.Client, userToken *oauth.Credentials,
+	albumKey string, since time.Time) ([]albumImageJSON, error) {
+
+	uri := apiAlbum + "/" + albumKey + "!images"
+	images := make([]albumImageJSON, 0, albumPageSize)
+	for start := 1; ; start += albumPageSize {
+		page, err := getSortedAlbumImagesPage(client, userToken, uri, imageSyncFilter, "DateUploaded",
+			start, albumPageSize)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, page.AlbumImage...)
+
+		for _, img := range page.AlbumImage {
+			if uploaded, err := time.Parse(time.RFC3339, img.DateTimeUploaded); err == nil && !uploaded.After(since) {
+				return images, nil
+			}
+		}
+		if page.Pages.Count == 0 || start+page.Pages.Count > page.Pages.Total {
+			return images, nil
+		}
+	}
+}
+
+// rowsToImages converts an album's rows to images with a key, hash, filename
+// and upload time.
+func rowsToImages(rows []imageRow) []albumImageJSON {
+	images := make([]albumImageJSON, 0, len(rows))
+	for _, row := range rows {
+		images = append(images, albumImageJSON{ImageKey: row.ImageKey, FileName: row.FileName,
+			ArchivedMD5: row.ArchivedMD5, DateTimeUploaded: row.Uploaded})
+	}
+	return images
+}
+
+// refreshAlbumImages fetches every image of an album and updates the album's
+// rows to match.  The images are also returned with the fields named by
+// filter, which must include the image key, hash and filename, and should
+// include the upload time.
+func refreshAlbumImages(client *http.Client, userToken *oauth.Credentials,
+	db *sql.DB, albumKey string, filter string) ([]albumImageJSON, error) {
+
+	album, err := getAlbumFields(client, userToken, albumKey, albumImagesStateFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := fetchAlbumImages(client, userToken, albumKey, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintln(os.Stderr, "Got", len(images), "images")
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	diff := diffImageRows(getImageRows(db, albumKey), images, false)
+	applyImageDiff(db, albumKey, diff, &imageSync{
+		ImagesLastUpdated: album.ImagesLastUpdated,
+		NewestUploaded:    newestUploaded("", images),
+		Synced:            now,
+		FullSynced:        now,
+	})
+
+	return images, nil
+}
+
+// updateAlbumImages brings an album's rows up to date and returns them as
+// images.  Unless full is true, or the album hasn't been fully refreshed
+// within fullImageRefreshAge, nothing is fetched if the album's images
+// haven't changed since the last refresh, and only new images are fetched if
+// they have.  If the rows still don't add up to the album's image count, every
+// image is fetched.
+func updateAlbumImages(client *http.Client, userToken *oauth.Credentials,
+	db *sql.DB, albumKey string, full bool) ([]albumImageJSON, error) {
+
+	sync, found := getImageSync(db, albumKey)
+	now := time.Now().UTC()
+	fullSynced, err := time.Parse(time.RFC3339, sync.FullSynced)
+	since, sinceErr := time.Parse(time.RFC3339, sync.NewestUploaded)
+	if !found || err != nil || sinceErr != nil || now.Sub(fullSynced) > fullImageRefreshAge {
+		full = true
+	}
+
+	if !full {
+		album, err := getAlbumFields(client, userToken, albumKey, albumImagesStateFilter)
+		if err != nil {
+			return nil, err
+		}
+
+		rows := getImageRows(db, albumKey)
+		sync.Synced = now.Format(time.RFC3339)
+		if album.ImagesLastUpdated != "" && album.ImagesLastUpdated == sync.ImagesLastUpdated &&
+			len(rows) == album.ImageCount {
+
+			fmt.Fprintln(os.Stderr, "No changes since the last refresh")
+			applyImageDiff(db, albumKey, imageRowDiff{}, &sync)
+			return rowsToImages(rows), nil
+		}
+
+		images, err := fetchNewAlbumImages(client, userToken, albumKey, since)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := diffImageRows(rows, images, true)
+		if len(rows)-len(diff.Deletes)+len(diff.Inserts) == album.ImageCount {
+			fmt.Fprintf(os.Stderr, "Got %d new images\n", len(diff.Inserts))
+			sync.ImagesLastUpdated = album.ImagesLastUpdated
+			sync.NewestUploaded = newestUploaded(sync.NewestUploaded, images)
+			applyImageDiff(db, albumKey, diff, &sync)
+			return rowsToImages(getImageRows(db, albumKey)), nil
+		}
+
+		fmt.Fprintln(os.Stderr, "Images were replaced or deleted, refreshing every image")
+	}
+
+	if _, err := refreshAlbumImages(client, userToken, db, albumKey, imageSyncFilter); err != nil {
+		return nil, err
+	}
+	return rowsToImages(getImageRows(db, albumKey)), nil
+}
+
+// imagesStale returns true if an album's rows were never fetched, or were
+// fetched longer than ttl ago.
+func imagesStale(sync imageSync, found bool, ttl time.Duration, now time.Time) bool {
+	if !found {
+		return true
+	}
+	synced, err := time.Parse(time.RFC3339, sync.Synced)
+	return err != nil || now.Sub(synced) > ttl
+}
+
+// refreshStaleImages brings an album's rows up to date if they're stale, so
+// duplicate checks see images added to the album by other means.  Failing to
+// refresh is only reported, since it's better to allow a duplicate upload
+// than to abort.
+func refreshStaleImages(client *http.Client, userToken *oauth.Credentials,
+	db *sql.DB, albumKey string, ttl time.Duration) {
+
+	sync, found := getImageSync(db, albumKey)
+	if !imagesStale(sync, found, ttl, time.Now().UTC()) {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "Refreshing images of album "+albumKey+" to check for duplicates")
+	if _, err := updateAlbumImages(client, userToken, db, albumKey, false); err != nil {
+		log.Println("Error refreshing album images, duplicates may not be detected: " + err.Error())
+	}
+}
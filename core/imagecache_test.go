@@ -0,0 +1,149 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var testImageRows = []imageRow{
+	{1, "k1", "hash-1", "a.jpg", "2026-10-01T10:00:00+00:00"},
+	{2, "k2", "hash-2", "b.jpg", "2026-10-01T11:00:00+00:00"},
+	{3, "", "hash-3", "c.jpg", ""},          // Written before image keys were stored.
+	{4, "k4", "hash-4", "/local/d.jpg", ""}, // Written by an upload.
+	{5, "k5", "hash-5", "e.jpg", ""},        // Deleted from the album.
+	{6, "k7", "hash-7", "g.jpg", ""},        // Written before upload times were stored.
+}
+
+var testAlbumImages = []albumImageJSON{
+	{ImageKey: "k1", ArchivedMD5: "hash-1", FileName: "a.jpg", DateTimeUploaded: "2026-10-01T10:00:00+00:00"},
+	{ImageKey: "k2", ArchivedMD5: "hash-2b", FileName: "b.jpg", DateTimeUploaded: "2026-10-01T11:00:00+00:00"}, // Replaced.
+	{ImageKey: "k3", ArchivedMD5: "hash-3", FileName: "c.jpg"},
+	{ImageKey: "k4", ArchivedMD5: "hash-4", FileName: "d.jpg"},
+	{ImageKey: "k6", ArchivedMD5: "hash-6", FileName: "f.jpg"}, // New.
+	{ImageKey: "k6", ArchivedMD5: "hash-6", FileName: "f.jpg"}, // Repeated by overlapping pages.
+	{ImageKey: "k7", ArchivedMD5: "hash-7", FileName: "g.jpg", DateTimeUploaded: "2026-10-02T10:00:00+00:00"},
+}
+
+func TestDiffImageRowsFull(t *testing.T) {
+	diff := diffImageRows(testImageRows, testAlbumImages, false)
+
+	if exp := []int64{2, 3, 4, 5, 6}; !reflect.DeepEqual(diff.Deletes, exp) {
+		t.Errorf("expected: %v, actual: %v", exp, diff.Deletes)
+	}
+	if exp := []string{"k2", "k3", "k4", "k6", "k7"}; !reflect.DeepEqual(selectedKeys(diff.Inserts), exp) {
+		t.Errorf("expected: %v, actual: %v", exp, selectedKeys(diff.Inserts))
+	}
+}
+
+func TestDiffImageRowsPartial(t *testing.T) {
+	// Only the newest images were fetched.
+	diff := diffImageRows(testImageRows, testAlbumImages[3:], true)
+
+	if exp := []int64{4, 6}; !reflect.DeepEqual(diff.Deletes, exp) {
+		t.Errorf("expected: %v, actual: %v", exp, diff.Deletes)
+	}
+	if exp := []string{"k4", "k6", "k7"}; !reflect.DeepEqual(selectedKeys(diff.Inserts), exp) {
+		t.Errorf("expected: %v, actual: %v", exp, selectedKeys(diff.Inserts))
+	}
+}
+
+func TestDiffImageRowsUnchanged(t *testing.T) {
+	diff := diffImageRows(testImageRows[:2], testAlbumImages[:1], true)
+	if len(diff.Deletes) != 0 || len(diff.Inserts) != 0 {
+		t.Errorf("expected no changes, actual: %v", diff)
+	}
+}
+
+func TestNewestUploaded(t *testing.T) {
+	images := []albumImageJSON{
+		{DateTimeUploaded: "2026-10-02T10:00:00+00:00"},
+		{DateTimeUploaded: "2026-10-03T10:00:00+00:00"},
+		{DateTimeUploaded: "not a time"},
+	}
+
+	if actual := newestUploaded("", images); actual != "2026-10-03T10:00:00+00:00" {
+		t.Errorf("expected: %s, actual: %s", "2026-10-03T10:00:00+00:00", actual)
+	}
+	if actual := newestUploaded("2026-10-04T10:00:00Z", images); actual != "2026-10-04T10:00:00Z" {
+		t.Errorf("expected: %s, actual: %s", "2026-10-04T10:00:00Z", actual)
+	}
+}
+
+func TestApplyImageDiff(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+
+	albumKey := "fake-album-key"
+	if _, found := getImageSync(db, albumKey); found {
+		t.Error("expected no sync time before the first refresh")
+	}
+
+	writeAlbumImages(db, albumKey, testAlbumImages[:2])
+	rows := getImageRows(db, albumKey)
+
+	sync := imageSync{
+		ImagesLastUpdated: "2026-10-03T10:00:00+00:00",
+		NewestUploaded:    "2026-10-02T10:00:00+00:00",
+		Synced:            "2026-10-04T10:00:00Z",
+		FullSynced:        "2026-10-01T10:00:00Z",
+	}
+	applyImageDiff(db, albumKey, imageRowDiff{Deletes: []int64{rows[0].ID}, Inserts: testAlbumImages[4:5]}, &sync)
+
+	actual := rowsToImages(getImageRows(db, albumKey))
+	if exp := []string{"k2", "k6"}; !reflect.DeepEqual(selectedKeys(actual), exp) {
+		t.Errorf("expected: %v, actual: %v", exp, selectedKeys(actual))
+	}
+	if !reflect.DeepEqual(testAlbumImages[1], actual[0]) {
+		t.Errorf("expected: %v, actual: %v", testAlbumImages[1], actual[0])
+	}
+	if actualSync, found := getImageSync(db, albumKey); !found || actualSync != sync {
+		t.Errorf("expected: %v, actual: %v", sync, actualSync)
+	}
+
+	// Removing the album's rows forces a full refresh next time.
+	removeAlbumImages(db, albumKey)
+	if _, found := getImageSync(db, albumKey); found {
+		t.Error("expected sync time removed with the album's rows")
+	}
+}
+
+func TestImagesStale(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2026-10-18T12:00:00Z")
+	sync := imageSync{Synced: "2026-10-18T00:00:00Z"}
+
+	cases := []struct {
+		sync  imageSync
+		found bool
+		ttl   time.Duration
+		exp   bool
+	}{
+		{imageSync{}, false, 24 * time.Hour, true},
+		{sync, true, 24 * time.Hour, false},
+		{sync, true, 6 * time.Hour, true},
+		{sync, true, 0, true},
+		{imageSync{Synced: "garbage"}, true, 24 * time.Hour, true},
+	}
+
+	for _, c := range cases {
+		if actual := imagesStale(c.sync, c.found, c.ttl, now); actual != c.exp {
+			t.Errorf("expected: %t, actual: %t for %v with ttl %s", c.exp, actual, c.sync, c.ttl)
+		}
+	}
+}
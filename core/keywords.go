@@ -0,0 +1,187 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// Ways the keywords command changes an image's keywords.
+const (
+	keywordsAdd    = "add"
+	keywordsRemove = "remove"
+	keywordsSet    = "set"
+)
+
+// keywordChange is the result of changing one image's keywords.
+type keywordChange struct {
+	Image albumImageJSON
+	New   []string
+	Err   error
+}
+
+// parseKeywords splits the keyword arguments on commas and semicolons, so
+// keywords may be given as separate arguments or as a list.
+func parseKeywords(args []string) []string {
+	keywords := make([]string, 0, len(args))
+	for _, arg := range args {
+		for _, keyword := range strings.FieldsFunc(arg, func(r rune) bool { return r == ',' || r == ';' }) {
+			if keyword = strings.TrimSpace(keyword); keyword != "" && !hasKeyword(keywords, keyword) {
+				keywords = append(keywords, keyword)
+			}
+		}
+	}
+	return keywords
+}
+
+// editKeywords returns the keywords an image has after applying op to its
+// current keywords.  Keywords are compared ignoring case.
+func editKeywords(op string, current []string, keywords []string) []string {
+	switch op {
+	case keywordsAdd:
+		edited := append([]string{}, current...)
+		for _, keyword := range keywords {
+			if !hasKeyword(edited, keyword) {
+				edited = append(edited, keyword)
+			}
+		}
+		return edited
+	case keywordsRemove:
+		edited := make([]string, 0, len(current))
+		for _, keyword := range current {
+			if !hasKeyword(keywords, keyword) {
+				edited = append(edited, keyword)
+			}
+		}
+		return edited
+	}
+
+	return append([]string{}, keywords...)
+}
+
+// sameKeywords returns true if both lists hold the same keywords in the same
+// order.
+func sameKeywords(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// keywordsCmd adds, removes or sets the keywords of the images picked by the
+// selector.  Images are changed in parallel, sending at most rate requests
+// per second.  Setting no keywords clears them, which is confirmed first
+// unless skipConfirm is true.
+func keywordsCmd(op string, albumKey string, keywords []string, sel imageSelector,
+	numParallel int, rate float64, skipConfirm bool) {
+
+	if op != keywordsAdd && op != keywordsRemove && op != keywordsSet {
+		log.Printf("Error: keywords must be followed by %s, %s or %s\n", keywordsAdd, keywordsRemove, keywordsSet)
+		return
+	}
+	if len(keywords) == 0 && op != keywordsSet {
+		log.Println("Error: no keywords given")
+		return
+	}
+	if !(rate > 0) {
+		log.Println("Error: -rate must be greater than 0")
+		return
+	}
+
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, albumKey, imageSelectFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	selected := selectImages(images, sel)
+	changes := make([]keywordChange, 0, len(selected))
+	for _, img := range selected {
+		edited := editKeywords(op, img.KeywordArray, keywords)
+		if !sameKeywords(img.KeywordArray, edited) {
+			changes = append(changes, keywordChange{Image: img, New: edited})
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No images need changes.")
+		return
+	}
+
+	if len(keywords) == 0 {
+		question := fmt.Sprintf("Remove every keyword from %d images in album %s?", len(changes), albumKey)
+		if !skipConfirm && !confirm(question) {
+			return
+		}
+	}
+
+	patchKeywords(&client, userToken, apiRoot, changes, numParallel, rate)
+
+	changed := 0
+	for _, change := range changes {
+		if change.Err != nil {
+			log.Println("Error changing " + change.Image.FileName + ": " + change.Err.Error())
+			continue
+		}
+		fmt.Printf("%s :: %s: %s -> %s\n", change.Image.FileName, change.Image.ImageKey,
+			strings.Join(change.Image.KeywordArray, ", "), strings.Join(change.New, ", "))
+		changed++
+	}
+
+	fmt.Printf("Changed %d images, %d failed, %d already up to date.\n",
+		changed, len(changes)-changed, len(selected)-len(changes))
+}
+
+// patchKeywords sends each image's new keywords to SmugMug, with numParallel
+// requests at once and at most rate requests per second.  root is prepended
+// to each image's URI.  The result of each request is stored in its change.
+// Rates too high to measure in nanoseconds aren't limited.
+func patchKeywords(client *http.Client, userToken *oauth.Credentials, root string,
+	changes []keywordChange, numParallel int, rate float64) {
+
+	var tick <-chan time.Time
+	if interval := time.Duration(float64(time.Second) / rate); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	inParallelN(len(changes), numParallel, func(i int) error {
+		if tick != nil {
+			<-tick
+		}
+		body := map[string]string{"Keywords": strings.Join(changes[i].New, "; ")}
+		changes[i].Err = apiSend(client, userToken, "PATCH", root+changes[i].Image.URI, body, nil)
+		return nil
+	})
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+func TestParseKeywords(t *testing.T) {
+	expected := []string{"beach", "sunset", "Smith Wedding"}
+	actual := parseKeywords([]string{"beach, sunset", "Smith Wedding;", "Beach"})
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
+
+func TestEditKeywords(t *testing.T) {
+	current := []string{"beach", "Sunset"}
+	tests := []struct {
+		op       string
+		keywords []string
+		expected []string
+	}{
+		{keywordsAdd, []string{"sunset", "family"}, []string{"beach", "Sunset", "family"}},
+		{keywordsRemove, []string{"SUNSET", "family"}, []string{"beach"}},
+		{keywordsSet, []string{"family"}, []string{"family"}},
+		{keywordsSet, []string{}, []string{}},
+	}
+
+	for _, test := range tests {
+		actual := editKeywords(test.op, current, test.keywords)
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("op: %s, expected: %q, actual: %q", test.op, test.expected, actual)
+		}
+	}
+
+	if !reflect.DeepEqual([]string{"beach", "Sunset"}, current) {
+		t.Errorf("current keywords changed to %q", current)
+	}
+}
+
+type KeywordsHandler struct {
+	mutex    sync.Mutex
+	keywords map[string]string
+}
+
+// Record the keywords sent for each image and fail for key-2.
+func (k *KeywordsHandler) Response(resp http.ResponseWriter, req *http.Request) {
+	var body map[string]string
+	json.NewDecoder(req.Body).Decode(&body)
+
+	k.mutex.Lock()
+	k.keywords[req.URL.Path] = body["Keywords"]
+	k.mutex.Unlock()
+
+	if req.Method != "PATCH" || req.URL.Path == "/api/v2/album/a/image/key-2-0" {
+		resp.WriteHeader(http.StatusBadRequest)
+		resp.Write([]byte("{\"Message\": \"bad image\"}"))
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte("{}"))
+}
+
+func TestPatchKeywords(t *testing.T) {
+	handler := KeywordsHandler{keywords: make(map[string]string)}
+	server := httptest.NewServer(http.HandlerFunc(handler.Response))
+	defer server.Close()
+
+	changes := []keywordChange{
+		{Image: albumImageJSON{URI: "/api/v2/album/a/image/key-1-0"}, New: []string{"beach", "sunset"}},
+		{Image: albumImageJSON{URI: "/api/v2/album/a/image/key-2-0"}, New: []string{"beach"}},
+		{Image: albumImageJSON{URI: "/api/v2/album/a/image/key-3-0"}, New: []string{}},
+	}
+
+	var client = http.Client{}
+	patchKeywords(&client, &oauth.Credentials{}, server.URL, changes, 2, 1000)
+
+	expected := map[string]string{
+		"/api/v2/album/a/image/key-1-0": "beach; sunset",
+		"/api/v2/album/a/image/key-2-0": "beach",
+		"/api/v2/album/a/image/key-3-0": "",
+	}
+	if !reflect.DeepEqual(expected, handler.keywords) {
+		t.Errorf("expected: %v, actual: %v", expected, handler.keywords)
+	}
+
+	failed := make([]string, 0, 1)
+	for _, change := range changes {
+		if change.Err != nil {
+			failed = append(failed, change.Image.URI)
+		}
+	}
+	if !reflect.DeepEqual([]string{"/api/v2/album/a/image/key-2-0"}, failed) {
+		t.Errorf("expected key-2 to fail, actual: %v", failed)
+	}
+}
+
+func TestPatchKeywordsUnlimitedRate(t *testing.T) {
+	handler := KeywordsHandler{keywords: make(map[string]string)}
+	server := httptest.NewServer(http.HandlerFunc(handler.Response))
+	defer server.Close()
+
+	changes := []keywordChange{
+		{Image: albumImageJSON{URI: "/api/v2/album/a/image/key-1-0"}, New: []string{"beach"}},
+	}
+
+	var client = http.Client{}
+	patchKeywords(&client, &oauth.Credentials{}, server.URL, changes, 2, 1e10)
+
+	if changes[0].Err != nil {
+		t.Errorf("expected no error, actual: %v", changes[0].Err)
+	}
+}
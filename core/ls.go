@@ -0,0 +1,151 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Fields requested for images listed by ls.
+const imageListFilter = imageSelectFilter + ",ArchivedSize,Caption,OriginalWidth,OriginalHeight,Hidden,WebUri"
+
+// Ways ls can sort images.  With no sort, images are in album order.
+const (
+	sortByName = "name"
+	sortBySize = "size"
+	sortByDate = "date"
+)
+
+// imageLess returns a function that orders images by the named field, or nil
+// if sortBy is empty.
+func imageLess(sortBy string) (func(a albumImageJSON, b albumImageJSON) bool, error) {
+	switch strings.ToLower(sortBy) {
+	case "":
+		return nil, nil
+	case sortByName:
+		return func(a albumImageJSON, b albumImageJSON) bool {
+			return strings.ToLower(a.FileName) < strings.ToLower(b.FileName)
+		}, nil
+	case sortBySize:
+		return func(a albumImageJSON, b albumImageJSON) bool {
+			return a.ArchivedSize < b.ArchivedSize
+		}, nil
+	case sortByDate:
+		// SmugMug's upload times are all in the same format, so they sort as
+		// strings.
+		return func(a albumImageJSON, b albumImageJSON) bool {
+			return a.DateTimeUploaded < b.DateTimeUploaded
+		}, nil
+	}
+
+	return nil, fmt.Errorf("-sortBy must be %s, %s or %s", sortByName, sortBySize, sortByDate)
+}
+
+// sortImages sorts the images in place with less, reversing the order if
+// reverse is true.  A nil less keeps the current order.
+func sortImages(images []albumImageJSON, less func(a albumImageJSON, b albumImageJSON) bool, reverse bool) {
+	if less != nil {
+		sort.SliceStable(images, func(i, j int) bool {
+			if reverse {
+				return less(images[j], images[i])
+			}
+			return less(images[i], images[j])
+		})
+	} else if reverse {
+		for i, j := 0, len(images)-1; i < j; i, j = i+1, j-1 {
+			images[i], images[j] = images[j], images[i]
+		}
+	}
+}
+
+// imageListLine formats an image as a line of tab separated columns for the
+// text format.
+func imageListLine(img imageRecord) string {
+	uploaded := img.DateTimeUploaded
+	if t, err := time.Parse(time.RFC3339, uploaded); err == nil {
+		uploaded = t.Local().Format("2006-01-02 15:04")
+	}
+
+	hidden := ""
+	if img.Hidden {
+		hidden = "hidden"
+	}
+
+	return strings.Join([]string{
+		img.FileName,
+		formatSize(img.ArchivedSize),
+		fmt.Sprintf("%dx%d", img.Width, img.Height),
+		uploaded,
+		hidden,
+		strings.Join(img.Keywords, ", "),
+		strings.Join(strings.Fields(img.Caption), " "),
+		img.WebURI,
+	}, "\t")
+}
+
+// listImages prints the details of the images in an album that the selector
+// picks, sorted by sortBy.
+func listImages(albumKey string, sel imageSelector, sortBy string, reverse bool, format listFormat) {
+	less, err := imageLess(sortBy)
+	if err != nil {
+		log.Println("Error: " + err.Error())
+		return
+	}
+
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, albumKey, imageListFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	images = selectImages(images, sel)
+	sortImages(images, less, reverse)
+
+	records := make([]imageRecord, 0, len(images))
+	for _, img := range images {
+		records = append(records, toImageRecord(albumKey, img))
+	}
+
+	// Only the text format's columns are aligned, since the others are meant
+	// for other programs.
+	if format.structured() {
+		err = format.writeList(os.Stdout, records, nil)
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		err = format.writeList(tw, records, func(i int) string {
+			return imageListLine(records[i])
+		})
+		if err == nil {
+			err = tw.Flush()
+		}
+	}
+	if err != nil {
+		log.Println("Error printing images: " + err.Error())
+	}
+}
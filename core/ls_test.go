@@ -0,0 +1,83 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lsTestImages() []albumImageJSON {
+	return []albumImageJSON{
+		{ImageKey: "key-1", FileName: "rose.jpg", ArchivedSize: 300, DateTimeUploaded: "2026-01-05T10:00:00+00:00"},
+		{ImageKey: "key-2", FileName: "Milk.jpg", ArchivedSize: 100, DateTimeUploaded: "2026-06-05T10:00:00+00:00"},
+		{ImageKey: "key-3", FileName: "orange.png", ArchivedSize: 200, DateTimeUploaded: "2026-03-05T10:00:00+00:00"},
+	}
+}
+
+func TestSortImages(t *testing.T) {
+	tests := []struct {
+		sortBy   string
+		reverse  bool
+		expected []string
+	}{
+		{"", false, []string{"key-1", "key-2", "key-3"}},
+		{"", true, []string{"key-3", "key-2", "key-1"}},
+		{"name", false, []string{"key-2", "key-3", "key-1"}},
+		{"Size", false, []string{"key-2", "key-3", "key-1"}},
+		{"date", true, []string{"key-2", "key-3", "key-1"}},
+	}
+
+	for _, test := range tests {
+		less, err := imageLess(test.sortBy)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		images := lsTestImages()
+		sortImages(images, less, test.reverse)
+		actual := selectedKeys(images)
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("sortBy: %s, reverse: %t, expected: %s, actual: %s",
+				test.sortBy, test.reverse, test.expected, actual)
+		}
+	}
+
+	if _, err := imageLess("color"); err == nil {
+		t.Error("Expected error for unknown sort")
+	}
+}
+
+func TestImageListLine(t *testing.T) {
+	img := imageRecord{
+		FileName:     "rose.jpg",
+		ArchivedSize: 2048,
+		Width:        4000,
+		Height:       3000,
+		Hidden:       true,
+		Keywords:     []string{"flower", "red"},
+		Caption:      "A rose\nin the garden",
+		WebURI:       "https://nick.smugmug.com/Flowers/i-key",
+	}
+
+	expected := []string{"rose.jpg", "2.0 KiB", "4000x3000", "", "hidden", "flower, red",
+		"A rose in the garden", "https://nick.smugmug.com/Flowers/i-key"}
+	actual := strings.Split(imageListLine(img), "\t")
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %q, actual: %q", expected, actual)
+	}
+}
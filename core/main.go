@@ -19,10 +19,12 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gomodule/oauth1/oauth"
 )
@@ -41,6 +43,79 @@ var smuggoDirFlag string
 // Whether duplicate images (same MD5 hash) are allowed when uploading.
 var allowDupesFlag bool
 
+// File containing a list of files to upload ("-" for stdin).
+var fromFlag string
+
+// Number of simultaneous transfers used by sync, download and backup.
+var parallelFlag int
+
+// What sync does with images that only exist in the album.
+var downloadFlag bool
+var deleteRemoteFlag bool
+
+// Skip confirmation prompts.
+var yesFlag bool
+
+// Selectors that pick images from an album.
+var nameFlag string
+var md5Flag string
+var beforeFlag string
+var afterFlag string
+var keywordFlag string
+var allFlag bool
+
+// Settings for new albums.
+var urlNameFlag string
+var privacyFlag string
+var descriptionFlag string
+var sortMethodFlag string
+var sortDirectionFlag string
+var passwordFlag string
+var cacheFlag bool
+
+// How many levels of folders tree shows (0 for all).
+var depthFlag int
+
+// Create missing destination folders when moving albums and folders.
+var createFlag bool
+
+// Get albums from SmugMug instead of the DB.
+var refreshFlag bool
+
+// Search albums with SmugMug instead of the DB.
+var remoteFlag bool
+
+// Maximum number of search results shown (0 asks before showing more).
+var limitFlag int
+
+// Maximum requests per second when changing images.
+var rateFlag float64
+
+// Settings for pruning staging albums.
+var olderThanFlag string
+var backupDirFlag string
+var dryRunFlag bool
+
+// Update smuggo's database to match SmugMug.
+var fixFlag bool
+
+// How ls sorts images.
+var sortByFlag string
+var reverseFlag bool
+
+// Fetch every image when refreshing an album's images.
+var fullFlag bool
+
+// How old an album's images may be before uploads refresh them.
+var cacheTTLFlag string
+
+// Result of uploads shown by history.
+var statusFlag string
+
+// Output format of listing commands.
+var formatFlag string
+var templateFlag string
+
 // loadToken imports tokens from the given JSON file.
 func loadToken(filename string) (*oauth.Credentials, error) {
 	bytes, err := ioutil.ReadFile(filename)
@@ -54,18 +129,78 @@ func loadToken(filename string) (*oauth.Credentials, error) {
 	return &token, nil
 }
 
+// confirm asks the user a yes or no question and returns true if the answer
+// is yes.
+func confirm(question string) bool {
+	fmt.Print(question + " [y/N] ")
+	var answer string
+	fmt.Scanln(&answer)
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes"
+}
+
 // usage gives minimal usage instructions.
 func usage() {
 	fmt.Println("Usage: ")
-	fmt.Println(os.Args[0] + " [-retries n] [-home path] [-allowDupes] apikey|auth|albums|images|search|upload|multiupload|version")
+	fmt.Println(os.Args[0] + " [-retries n] [-home path] [-allowDupes] [-from file] [-parallel n] [-yes] apikey|auth|albums|images|search|upload|multiupload|sync|download|backup|rm|move|copy|promote|prune|mkalbum|album|ls|keywords|verify|stats|history|db|tree|mv-album|mv-folder|version")
 	fmt.Println("\tapikey")
 	fmt.Println("\tauth")
 	fmt.Println("\talbums")
+	fmt.Println("\t\t-refresh gets the albums from SmugMug instead of smuggo's database")
 	fmt.Println("\timages <album key>")
+	fmt.Println("\t\t-full fetches every image instead of only images added since the last refresh")
 	fmt.Println("\tsearch <search term 1> ... <search term n>")
+	fmt.Println("\t\t-remote uses SmugMug's search instead of smuggo's database")
+	fmt.Println("\t\t-limit n shows at most n results, -all shows every result without asking")
+	fmt.Println("\tsearch images <search term 1> ... <search term n>")
+	fmt.Println("\t\tnarrow results with -keyword word, -before date and -after date")
 	fmt.Println("\tupload <album key> <filename>")
 	fmt.Println("\tmultiupload <# parallel uploads> <album key> <filename 1> ... <filename n>")
+	fmt.Println("\t\t-cacheTTL age refreshes the album's images before uploading if older, default 24h")
+	fmt.Println("\t\t-from reads additional filenames, one per line or NUL delimited, from a file (- for stdin)")
+	fmt.Println("\tsync <local dir> <album key>")
+	fmt.Println("\t\t-download saves images only in the album to the local dir")
+	fmt.Println("\t\t-deleteRemote deletes images only in the album")
+	fmt.Println("\tdownload <album key> <dir>")
+	fmt.Println("\tbackup <dir>")
+	fmt.Println("\trm <album key>")
+	fmt.Println("\t\tselect images with -name pattern, -md5 hash, -before date, -after date, -keyword word or -all")
+	fmt.Println("\tmove <source album key> <destination album key>")
+	fmt.Println("\tcopy <source album key> <destination album key>")
+	fmt.Println("\t\tselect images the same way as rm")
+	fmt.Println("\tprune <album key>")
+	fmt.Println("\t\t-olderThan age (required, such as 90d) picks images to delete if they're also")
+	fmt.Println("\t\tin another album, in the -backupDir folder or saved by backup")
+	fmt.Println("\t\t-dryRun lists the images without deleting")
+	fmt.Println("\tpromote <staging album key> <destination album key>")
+	fmt.Println("\t\tselect images the same way as rm, skips images already in the destination")
+	fmt.Println("\tmkalbum <folder path> <name>")
+	fmt.Println("\t\t-urlName, -privacy, -description, -sortMethod, -sortDirection, -password, -cache")
+	fmt.Println("\talbum set <album key> <field=value 1> ... <field=value n>")
+	fmt.Println("\talbum delete <album key>")
+	fmt.Println("\tls <album key>")
+	fmt.Println("\t\tnarrow with the same selectors as rm, -sortBy name, size or date, -reverse")
+	fmt.Println("\tkeywords add|remove|set <album key> <keyword 1> ... <keyword n>")
+	fmt.Println("\t\tselect images the same way as rm, -rate limits requests per second,")
+	fmt.Println("\t\tset with no keywords clears them after asking unless -yes is given")
+	fmt.Println("\tverify <album key>")
+	fmt.Println("\t\t-all checks every album in smuggo's database, -fix updates the database")
+	fmt.Println("\tstats <album key>")
+	fmt.Println("\thistory [album key]")
+	fmt.Println("\t\tnarrow with -name pattern, -before date, -after date, -status result and -limit n")
+	fmt.Println("\tdb export <file or folder>")
+	fmt.Println("\t\twrites every table to a JSON file (- for stdout), or with -format csv, a folder of CSV files")
+	fmt.Println("\tdb import <file or folder>")
+	fmt.Println("\t\tmerges an export into the database, skipping rows already in it")
+	fmt.Println("\tdb backup <file>")
+	fmt.Println("\tdb vacuum")
+	fmt.Println("\ttree [folder path]")
+	fmt.Println("\t\t-depth limits the number of folder levels shown")
+	fmt.Println("\tmv-album <album key> <folder path>")
+	fmt.Println("\tmv-folder <folder path> <destination folder path>")
+	fmt.Println("\t\t-create creates the destination folder if it doesn't exist")
 	fmt.Println("\tversion")
+	fmt.Println("\nalbums, images, ls, search and history print -format text, json, csv or tsv, or use -template")
 	fmt.Println("\nhome defaults to ~/" + smuggoDir + " if not specified.")
 	fmt.Printf("Number of retries defaults to 2 if not specified.\n\n")
 }
@@ -76,6 +211,49 @@ func init() {
 		"smuggo home folder (defaults to ~/"+smuggoDir+")")
 	flag.BoolVar(&allowDupesFlag, "allowDupes", false,
 		"allow duplicate images during uploads (defaults to no)")
+	flag.StringVar(&fromFlag, "from", "",
+		"file listing filenames to upload, newline or NUL delimited (- for stdin)")
+	flag.IntVar(&parallelFlag, "parallel", 4, "number of simultaneous transfers for sync, download, backup and keywords")
+	flag.BoolVar(&downloadFlag, "download", false,
+		"sync downloads images that are only in the album")
+	flag.BoolVar(&deleteRemoteFlag, "deleteRemote", false,
+		"sync deletes images that are only in the album")
+	flag.BoolVar(&yesFlag, "yes", false, "do not ask for confirmation")
+	flag.StringVar(&nameFlag, "name", "", "select images whose filename matches the pattern")
+	flag.StringVar(&md5Flag, "md5", "", "select images with the MD5 hash")
+	flag.StringVar(&beforeFlag, "before", "",
+		"select images uploaded before the date (YYYY-MM-DD or RFC 3339)")
+	flag.StringVar(&afterFlag, "after", "",
+		"select images uploaded on or after the date (YYYY-MM-DD or RFC 3339)")
+	flag.StringVar(&keywordFlag, "keyword", "", "select images with the keyword")
+	flag.BoolVar(&allFlag, "all", false, "select all images, or show all search results without asking")
+	flag.StringVar(&urlNameFlag, "urlName", "", "URL name of a new album (defaults to one based on its name)")
+	flag.StringVar(&privacyFlag, "privacy", "", "privacy of a new album: Public, Unlisted or Private")
+	flag.StringVar(&descriptionFlag, "description", "", "description of a new album")
+	flag.StringVar(&sortMethodFlag, "sortMethod", "", "sort method of a new album, such as DateTaken")
+	flag.StringVar(&sortDirectionFlag, "sortDirection", "", "sort direction of a new album: Ascending or Descending")
+	flag.StringVar(&passwordFlag, "password", "", "password that protects a new album")
+	flag.BoolVar(&cacheFlag, "cache", false, "save a new album to smuggo's database")
+	flag.IntVar(&depthFlag, "depth", 0, "number of folder levels shown by tree (0 for all)")
+	flag.BoolVar(&createFlag, "create", false, "create missing destination folders when moving")
+	flag.BoolVar(&refreshFlag, "refresh", false, "get albums from SmugMug instead of smuggo's database")
+	flag.BoolVar(&remoteFlag, "remote", false, "search albums with SmugMug instead of smuggo's database")
+	flag.IntVar(&limitFlag, "limit", 0,
+		"maximum number of search results (0 asks before showing more) or latest uploads shown by history")
+	flag.Float64Var(&rateFlag, "rate", 5, "maximum requests per second when changing images")
+	flag.StringVar(&olderThanFlag, "olderThan", "", "prune images uploaded longer ago than this, such as 90d")
+	flag.StringVar(&backupDirFlag, "backupDir", "", "local folder with copies of images that prune may delete")
+	flag.BoolVar(&dryRunFlag, "dryRun", false, "list what prune would delete without deleting")
+	flag.BoolVar(&fixFlag, "fix", false, "update smuggo's database where verify finds differences")
+	flag.StringVar(&sortByFlag, "sortBy", "", "sort images listed by ls by name, size or date")
+	flag.BoolVar(&reverseFlag, "reverse", false, "reverse the order of images listed by ls")
+	flag.BoolVar(&fullFlag, "full", false, "fetch every image of the album, not only new images")
+	flag.StringVar(&cacheTTLFlag, "cacheTTL", "24h",
+		"refresh the album's images before uploading if fetched longer ago than this, such as 12h or 7d")
+	flag.StringVar(&statusFlag, "status", "", "show uploads with this result: uploaded, duplicate or failed")
+	flag.StringVar(&formatFlag, "format", formatText, "output format of listing commands: text, json, csv or tsv")
+	flag.StringVar(&templateFlag, "template", "",
+		"Go text/template used to print each result of listing commands, e.g. '{{.AlbumKey}} {{.Name}}'")
 }
 
 func main() {
@@ -85,6 +263,12 @@ func main() {
 		return
 	}
 
+	format, err := newListFormat(formatFlag, templateFlag)
+	if err != nil {
+		log.Println("Error: " + err.Error())
+		return
+	}
+
 	loweredCmd := strings.ToLower(flag.Arg(0))
 	if loweredCmd == "apikey" {
 		apikey()
@@ -93,6 +277,45 @@ func main() {
 
 	initDB()
 
+	// Commands that only use smuggo's database don't need an API key.
+	switch loweredCmd {
+	case "history":
+		if len(flag.Args()) > 2 {
+			usage()
+			return
+		}
+		filter, err := newHistoryFilter(nameFlag, statusFlag, beforeFlag, afterFlag)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		history(flag.Arg(1), filter, limitFlag, format)
+		return
+	case "db":
+		if len(flag.Args()) < 2 {
+			usage()
+			return
+		}
+		subCmd := strings.ToLower(flag.Arg(1))
+		if (subCmd == "vacuum") != (len(flag.Args()) == 2) || len(flag.Args()) > 3 {
+			usage()
+			return
+		}
+		switch subCmd {
+		case "export":
+			dbExport(flag.Arg(2), format)
+		case "import":
+			dbImport(flag.Arg(2))
+		case "backup":
+			dbBackup(flag.Arg(2))
+		case "vacuum":
+			dbVacuum()
+		default:
+			usage()
+		}
+		return
+	}
+
 	// Normal code path where an API key must exist.
 	authInit()
 
@@ -104,19 +327,38 @@ func main() {
 			usage()
 			return
 		}
-		upload(allowDupesFlag, flag.Arg(1), flag.Arg(2))
+		cacheTTL, err := parseAge(cacheTTLFlag)
+		if err != nil {
+			log.Println("Error: bad -cacheTTL: " + err.Error())
+			return
+		}
+		upload(allowDupesFlag, flag.Arg(1), flag.Arg(2), cacheTTL)
 	case "images":
-		albumImages(flag.Arg(1))
+		albumImages(flag.Arg(1), fullFlag, format)
 	case "albums":
-		albums()
+		albums(refreshFlag, format)
 	case "search":
 		if len(flag.Args()) < 2 {
 			usage()
 			return
 		}
-		search(flag.Args()[1:])
+		if strings.ToLower(flag.Arg(1)) == "images" {
+			// Every image is picked unless narrowed by the other selectors.
+			sel, err := newImageSelector("", "", beforeFlag, afterFlag, keywordFlag, true)
+			if err != nil {
+				log.Println("Error: " + err.Error())
+				return
+			}
+			searchImagesCmd(flag.Args()[2:], sel, limitFlag, allFlag, format)
+			return
+		}
+		search(flag.Args()[1:], remoteFlag, limitFlag, allFlag, format)
 	case "multiupload":
-		if len(flag.Args()) < 4 {
+		minArgs := 4
+		if fromFlag != "" {
+			minArgs = 3
+		}
+		if len(flag.Args()) < minArgs {
 			usage()
 			return
 		}
@@ -125,7 +367,197 @@ func main() {
 			usage()
 			return
 		}
-		multiUpload(numParallel, allowDupesFlag, flag.Arg(2), flag.Args()[3:])
+		cacheTTL, err := parseAge(cacheTTLFlag)
+		if err != nil {
+			log.Println("Error: bad -cacheTTL: " + err.Error())
+			return
+		}
+		var manifest []string
+		if fromFlag != "" {
+			manifest, err = readManifest(fromFlag)
+			if err != nil {
+				log.Println("Error reading file list: " + err.Error())
+				return
+			}
+		}
+		multiUpload(numParallel, allowDupesFlag, flag.Arg(2), flag.Args()[3:], manifest, cacheTTL)
+	case "sync":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		if parallelFlag < 1 {
+			log.Println("Error, must transfer at least 1 file at a time!")
+			return
+		}
+		syncAlbum(flag.Arg(1), flag.Arg(2), downloadFlag, deleteRemoteFlag)
+	case "download":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		if parallelFlag < 1 {
+			log.Println("Error, must transfer at least 1 file at a time!")
+			return
+		}
+		downloadAlbum(flag.Arg(1), flag.Arg(2))
+	case "backup":
+		if len(flag.Args()) != 2 {
+			usage()
+			return
+		}
+		if parallelFlag < 1 {
+			log.Println("Error, must transfer at least 1 file at a time!")
+			return
+		}
+		backup(flag.Arg(1))
+	case "rm":
+		if len(flag.Args()) != 2 {
+			usage()
+			return
+		}
+		sel, err := newImageSelector(nameFlag, md5Flag, beforeFlag, afterFlag, keywordFlag, allFlag)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		removeImages(flag.Arg(1), sel, yesFlag)
+	case "move", "copy":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		sel, err := newImageSelector(nameFlag, md5Flag, beforeFlag, afterFlag, keywordFlag, allFlag)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		transferImages(flag.Arg(1), flag.Arg(2), sel, loweredCmd == "move")
+	case "prune":
+		if len(flag.Args()) != 2 || olderThanFlag == "" {
+			usage()
+			return
+		}
+		age, err := parseAge(olderThanFlag)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		// Old images are picked, optionally narrowed by the other selectors.
+		sel, err := newImageSelector(nameFlag, md5Flag, "", "", keywordFlag, true)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		sel.Before = time.Now().Add(-age)
+		pruneAlbum(flag.Arg(1), sel, backupDirFlag, dryRunFlag, yesFlag)
+	case "promote":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		sel, err := newImageSelector(nameFlag, md5Flag, beforeFlag, afterFlag, keywordFlag, allFlag)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		promoteImages(flag.Arg(1), flag.Arg(2), sel)
+	case "mkalbum":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		settings := albumSettings{
+			URLName:       urlNameFlag,
+			Description:   descriptionFlag,
+			SortMethod:    sortMethodFlag,
+			SortDirection: sortDirectionFlag,
+			Password:      passwordFlag,
+		}
+		if privacyFlag != "" {
+			privacy, err := normalizePrivacy(privacyFlag)
+			if err != nil {
+				log.Println("Error: " + err.Error())
+				return
+			}
+			settings.Privacy = privacy
+		}
+		mkalbum(flag.Arg(1), flag.Arg(2), settings, cacheFlag)
+	case "album":
+		if len(flag.Args()) < 3 {
+			usage()
+			return
+		}
+		switch strings.ToLower(flag.Arg(1)) {
+		case "set":
+			if len(flag.Args()) < 4 {
+				usage()
+				return
+			}
+			setAlbum(flag.Arg(2), flag.Args()[3:])
+		case "delete":
+			deleteAlbum(flag.Arg(2), yesFlag)
+		default:
+			usage()
+		}
+	case "ls":
+		if len(flag.Args()) != 2 {
+			usage()
+			return
+		}
+		// Every image is listed unless narrowed by the selectors.
+		sel, err := newImageSelector(nameFlag, md5Flag, beforeFlag, afterFlag, keywordFlag, true)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		listImages(flag.Arg(1), sel, sortByFlag, reverseFlag, format)
+	case "keywords":
+		if len(flag.Args()) < 3 {
+			usage()
+			return
+		}
+		if parallelFlag < 1 {
+			log.Println("Error, must change at least 1 image at a time!")
+			return
+		}
+		sel, err := newImageSelector(nameFlag, md5Flag, beforeFlag, afterFlag, keywordFlag, allFlag)
+		if err != nil {
+			log.Println("Error: " + err.Error())
+			return
+		}
+		keywordsCmd(strings.ToLower(flag.Arg(1)), flag.Arg(2), parseKeywords(flag.Args()[3:]),
+			sel, parallelFlag, rateFlag, yesFlag)
+	case "verify":
+		if allFlag == (len(flag.Args()) == 2) || len(flag.Args()) > 2 {
+			usage()
+			return
+		}
+		verify(flag.Arg(1), fixFlag)
+	case "stats":
+		if len(flag.Args()) != 2 {
+			usage()
+			return
+		}
+		albumStatsCmd(flag.Arg(1))
+	case "tree":
+		if len(flag.Args()) > 2 {
+			usage()
+			return
+		}
+		tree(flag.Arg(1), depthFlag)
+	case "mv-album":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		mvAlbum(flag.Arg(1), flag.Arg(2), createFlag)
+	case "mv-folder":
+		if len(flag.Args()) != 3 {
+			usage()
+			return
+		}
+		mvFolder(flag.Arg(1), flag.Arg(2), createFlag)
 	case "version":
 		fmt.Println(os.Args[0] + " " + version + "\n")
 		return
@@ -0,0 +1,153 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+// migration upgrades a table to version from the version before it.
+type migration struct {
+	table   string
+	version int
+	stmts   []string
+}
+
+// Migrations in the order they're applied.  When changing a table's schema,
+// update its create SQL and version, and add a migration that upgrades the
+// previous version in place.
+var migrations = []migration{
+	// The album_key index was declared in version 1, but never created.
+	{imageTable, 2, []string{imgTableAlbumKeyIndexSQL}},
+	// Image keys let refreshes change only the rows of images that changed.
+	{imageTable, 3, []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN image_key TEXT NOT NULL DEFAULT '';", imageTable)}},
+	// Upload times let images be listed from the rows with the same fields
+	// as when they're fetched.
+	{imageTable, 4, []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN uploaded TEXT NOT NULL DEFAULT '';", imageTable)}},
+}
+
+var verTableUpdateSQL = fmt.Sprintf("UPDATE %s SET version = ? WHERE name = ?;", versionTable)
+
+// latestTableVersions returns the version of each table that this version of
+// smuggo uses.
+func latestTableVersions() map[string]int {
+	versions := map[string]int{imageTable: imageTableVersion}
+	for _, table := range laterTables {
+		versions[table.name] = table.version
+	}
+	return versions
+}
+
+// getTableVersions reads the version of each table in the DB.
+func getTableVersions(db *sql.DB) (map[string]int, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT name, version FROM %s;", versionTable))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	versions := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var version int
+		if err := rows.Scan(&name, &version); err != nil {
+			return nil, err
+		}
+		versions[name] = version
+	}
+
+	return versions, rows.Err()
+}
+
+// pendingMigrations returns the migrations needed to bring the tables up to
+// date, in order.
+func pendingMigrations(versions map[string]int) []migration {
+	pending := make([]migration, 0, len(migrations))
+	for _, m := range migrations {
+		if version, found := versions[m.table]; found && version < m.version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// backupDBFile writes a copy of the DB to backupFile.
+func backupDBFile(db *sql.DB, backupFile string) error {
+	_, err := db.Exec("VACUUM INTO ?;", backupFile)
+	return err
+}
+
+// applyMigration runs a migration and records the table's new version in a
+// single transaction, so a failed migration leaves the table unchanged.
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range m.stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(verTableUpdateSQL, m.version, m.table); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrateTables upgrades out of date tables in place.  Before any changes, a
+// copy of the DB is written to backupFile, unless backupFile is empty.
+func migrateTables(db *sql.DB, backupFile string) error {
+	versions, err := getTableVersions(db)
+	if err != nil {
+		return err
+	}
+
+	latest := latestTableVersions()
+	for name, version := range versions {
+		if latestVersion, known := latest[name]; known && version > latestVersion {
+			return fmt.Errorf("Table: %s version is %d, but this version of smuggo only supports up to %d",
+				name, version, latestVersion)
+		}
+	}
+
+	pending := pendingMigrations(versions)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	if backupFile != "" {
+		if err := backupDBFile(db, backupFile); err != nil {
+			return fmt.Errorf("backing up database before upgrading: %v", err)
+		}
+		log.Println("Backed up database to " + backupFile)
+	}
+
+	for _, m := range pending {
+		if err := applyMigration(db, m); err != nil {
+			return fmt.Errorf("upgrading table %s to version %d: %v", m.table, m.version, err)
+		}
+		log.Printf("Upgraded table %s to version %d\n", m.table, m.version)
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func indexExists(t *testing.T, db *sql.DB, name string) bool {
+	var count int
+	row := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?;", name)
+	if err := row.Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count > 0
+}
+
+// createV1Tables creates a DB as the first version of smuggo did.
+func createV1Tables(t *testing.T, db *sql.DB) {
+	_, err := db.Exec(fmt.Sprintf(
+		"CREATE TABLE %s (id INTEGER NOT NULL PRIMARY KEY, album_key TEXT, hash TEXT, filename TEXT);\n%s\n%s",
+		imageTable, verTableCreateSQL, imgTableHashIndexSQL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(fmt.Sprintf("INSERT INTO %s (name, version) VALUES (?, 1);", versionTable), imageTable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	createLaterTables(db)
+}
+
+func TestMigrationsMatchTableVersions(t *testing.T) {
+	latest := latestTableVersions()
+	previous := make(map[string]int)
+	for _, m := range migrations {
+		latestVersion, known := latest[m.table]
+		if !known {
+			t.Errorf("migration for unknown table %s", m.table)
+			continue
+		}
+		if m.version > latestVersion {
+			t.Errorf("migration of %s to version %d is newer than version %d", m.table, m.version, latestVersion)
+		}
+		if m.version <= previous[m.table] {
+			t.Errorf("migrations of %s out of order at version %d", m.table, m.version)
+		}
+		previous[m.table] = m.version
+	}
+}
+
+func TestMigrateImageTableV1(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createV1Tables(t, db)
+	if _, err := db.Exec(fmt.Sprintf("INSERT INTO %s (album_key, hash, filename) VALUES (?, ?, ?);", imageTable),
+		"fake-album-key", "fake-hash", "img.jpg"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := migrateTables(db, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateTables(db); err != nil {
+		t.Error(err)
+	}
+	if !indexExists(t, db, imageTableAlbumKeyIndexName) {
+		t.Error("album_key index not created")
+	}
+
+	rows := getImageRows(db, "fake-album-key")
+	if len(rows) != 1 || rows[0].ImageKey != "" || rows[0].FileName != "img.jpg" {
+		t.Errorf("unexpected rows after upgrade: %v", rows)
+	}
+	writeAlbumImages(db, "fake-album-key", []albumImageJSON{{ImageKey: "fake-image-key"}})
+	if rows = getImageRows(db, "fake-album-key"); len(rows) != 2 || rows[1].ImageKey != "fake-image-key" {
+		t.Errorf("unexpected rows after writing image key: %v", rows)
+	}
+
+	// Already up to date, so nothing to do.
+	if err := migrateTables(db, ""); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMigrateNewerVersion(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion+1)
+	if err := migrateTables(db, ""); err == nil {
+		t.Error("expected error for table newer than supported")
+	}
+}
+
+func TestMigrateBacksUp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smuggo-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "images.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	createV1Tables(t, db)
+	backupFile := filepath.Join(dir, "images.db.bak")
+	if err := migrateTables(db, backupFile); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := sql.Open("sqlite3", backupFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+
+	versions, err := getTableVersions(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions[imageTable] != 1 {
+		t.Errorf("expected: backup at version 1, actual: %d", versions[imageTable])
+	}
+}
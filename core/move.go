@@ -0,0 +1,166 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+const (
+	apiMoveImages    = "!moveimages"
+	apiCollectImages = "!collectimages"
+)
+
+// Maximum number of images sent in a single move or collect request.
+const imageBatchSize = 50
+
+// transferImages moves or copies the images picked by the selector from one
+// album to another.
+func transferImages(srcKey string, destKey string, sel imageSelector, move bool) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, srcKey, imageSelectFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	selected := selectImages(images, sel)
+	if len(selected) == 0 {
+		fmt.Println("No matching images.")
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	count := sendImages(&client, userToken, db, apiAlbum+"/"+destKey, srcKey, destKey, selected, move)
+	verb := "Copied"
+	if move {
+		verb = "Moved"
+	}
+	fmt.Printf("%s %d of %d images to album %s.\n", verb, count, len(selected), destKey)
+}
+
+// promoteImages moves the images picked by the selector from a staging album
+// to its destination album, leaving behind images whose hash is already in
+// the destination album according to the image table.
+func promoteImages(stagingKey string, destKey string, sel imageSelector) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, stagingKey, imageSelectFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	selected := selectImages(images, sel)
+	if len(selected) == 0 {
+		fmt.Println("No matching images.")
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	promoted, dupes := splitDuplicates(db, destKey, selected)
+	for _, img := range dupes {
+		fmt.Println("Skipping " + img.FileName + ", already in album " + destKey)
+	}
+	if len(promoted) == 0 {
+		fmt.Println("All matching images are already in album " + destKey + ".")
+		return
+	}
+
+	count := sendImages(&client, userToken, db, apiAlbum+"/"+destKey, stagingKey, destKey, promoted, true)
+	fmt.Printf("Promoted %d of %d images to album %s, skipped %d duplicates.\n",
+		count, len(selected), destKey, len(dupes))
+}
+
+// splitDuplicates separates images whose hash is already in the album, or
+// that repeat the hash of an earlier image in the list, from the rest.
+func splitDuplicates(db *sql.DB, albumKey string, images []albumImageJSON) ([]albumImageJSON, []albumImageJSON) {
+	unique := make([]albumImageJSON, 0, len(images))
+	dupes := make([]albumImageJSON, 0)
+	seen := make(map[string]bool, len(images))
+	for _, img := range images {
+		if seen[img.ArchivedMD5] || len(getDuplicateImages(db, albumKey, img.ArchivedMD5)) > 0 {
+			dupes = append(dupes, img)
+			continue
+		}
+		seen[img.ArchivedMD5] = true
+		unique = append(unique, img)
+	}
+
+	return unique, dupes
+}
+
+// sendImages moves or copies images to the album served by destURI, in
+// batches.  The image table is updated for both albums after each batch, so
+// duplicate checks stay correct.  Returns the number of images sent.
+func sendImages(client *http.Client, userToken *oauth.Credentials, db *sql.DB,
+	destURI string, srcKey string, destKey string, images []albumImageJSON, move bool) int {
+
+	endpoint, field := apiCollectImages, "CollectUris"
+	if move {
+		endpoint, field = apiMoveImages, "MoveUris"
+	}
+
+	count := 0
+	for start := 0; start < len(images); start += imageBatchSize {
+		end := start + imageBatchSize
+		if end > len(images) {
+			end = len(images)
+		}
+		batch := images[start:end]
+
+		uris := make([]string, 0, len(batch))
+		for _, img := range batch {
+			uris = append(uris, img.URI)
+		}
+
+		body := map[string]string{field: strings.Join(uris, ",")}
+		if err := apiSend(client, userToken, "POST", destURI+endpoint, body, nil); err != nil {
+			log.Printf("Error sending images %d to %d: %v\n", start+1, end, err)
+			continue
+		}
+
+		writeAlbumImages(db, destKey, batch)
+		if move {
+			for _, img := range batch {
+				removeImageData(db, srcKey, img)
+			}
+		}
+		count += len(batch)
+	}
+
+	return count
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+type MoveHandler struct {
+	paths  []string
+	bodies []map[string]string
+}
+
+// Record the endpoint and body of each request and indicate success.
+func (m *MoveHandler) OkResponse(resp http.ResponseWriter, req *http.Request) {
+	var body map[string]string
+	json.NewDecoder(req.Body).Decode(&body)
+	m.paths = append(m.paths, req.URL.Path)
+	m.bodies = append(m.bodies, body)
+	resp.WriteHeader(http.StatusOK)
+	resp.Write([]byte("{}"))
+}
+
+var moveTestImages = []albumImageJSON{
+	{ImageKey: "key-1", FileName: "milk.jpg", ArchivedMD5: "hash-1", URI: "/api/v2/album/src/image/key-1-0"},
+	{ImageKey: "key-2", FileName: "rose.jpg", ArchivedMD5: "hash-2", URI: "/api/v2/album/src/image/key-2-0"},
+}
+
+func TestMoveImagesUpdatesBothAlbums(t *testing.T) {
+	handler := MoveHandler{}
+	server := httptest.NewServer(http.HandlerFunc(handler.OkResponse))
+	defer server.Close()
+
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	writeAlbumImages(db, "src", moveTestImages)
+
+	var client = http.Client{}
+	count := sendImages(&client, &oauth.Credentials{}, db, server.URL+"/api/v2/album/dest",
+		"src", "dest", moveTestImages, true)
+	if count != 2 {
+		t.Errorf("Expected 2 images moved, actual %d", count)
+	}
+
+	if len(handler.paths) != 1 || handler.paths[0] != "/api/v2/album/dest!moveimages" {
+		t.Errorf("Expected one request to !moveimages, actual %v", handler.paths)
+	}
+	expUris := "/api/v2/album/src/image/key-1-0,/api/v2/album/src/image/key-2-0"
+	if len(handler.bodies) == 1 && handler.bodies[0]["MoveUris"] != expUris {
+		t.Errorf("Expected MoveUris %s, actual %s", expUris, handler.bodies[0]["MoveUris"])
+	}
+
+	if dupes := getDuplicateImages(db, "src", "hash-1"); len(dupes) != 0 {
+		t.Errorf("Expected image removed from source album, found %v", dupes)
+	}
+	if dupes := getDuplicateImages(db, "dest", "hash-2"); len(dupes) != 1 {
+		t.Errorf("Expected image in destination album, found %v", dupes)
+	}
+
+	keys := make([]string, 0, 2)
+	for _, row := range getImageRows(db, "dest") {
+		keys = append(keys, row.ImageKey)
+	}
+	if !reflect.DeepEqual([]string{"key-1", "key-2"}, keys) {
+		t.Errorf("expected: [key-1 key-2], actual: %v", keys)
+	}
+}
+
+func TestCopyImagesKeepsSourceAlbum(t *testing.T) {
+	handler := MoveHandler{}
+	server := httptest.NewServer(http.HandlerFunc(handler.OkResponse))
+	defer server.Close()
+
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	writeAlbumImages(db, "src", moveTestImages)
+
+	var client = http.Client{}
+	sendImages(&client, &oauth.Credentials{}, db, server.URL+"/api/v2/album/dest",
+		"src", "dest", moveTestImages[:1], false)
+
+	if len(handler.bodies) != 1 || handler.bodies[0]["CollectUris"] != moveTestImages[0].URI {
+		t.Errorf("Expected one !collectimages request, actual %v", handler.bodies)
+	}
+
+	if dupes := getDuplicateImages(db, "src", "hash-1"); len(dupes) != 1 {
+		t.Errorf("Expected image to stay in source album, found %v", dupes)
+	}
+	if dupes := getDuplicateImages(db, "dest", "hash-1"); len(dupes) != 1 {
+		t.Errorf("Expected image in destination album, found %v", dupes)
+	}
+}
+
+func TestSplitDuplicates(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	writeTestImageData(db, "dest", []imageJSON{{"hash-2", "rose.jpg"}})
+
+	images := []albumImageJSON{
+		{ImageKey: "key-1", ArchivedMD5: "hash-1"},
+		{ImageKey: "key-2", ArchivedMD5: "hash-2"},
+		{ImageKey: "key-3", ArchivedMD5: "hash-1"},
+		{ImageKey: "key-4", ArchivedMD5: "hash-4"},
+	}
+	unique, dupes := splitDuplicates(db, "dest", images)
+
+	if keys := selectedKeys(unique); !reflect.DeepEqual([]string{"key-1", "key-4"}, keys) {
+		t.Errorf("expected: [key-1 key-4], actual: %s", keys)
+	}
+	if keys := selectedKeys(dupes); !reflect.DeepEqual([]string{"key-2", "key-3"}, keys) {
+		t.Errorf("expected: [key-2 key-3], actual: %s", keys)
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+const (
+	apiNode         = apiRoot + "/api/v2/node"
+	apiNodeChildren = "!children"
+	apiMoveNodes    = "!movenodes"
+	nodeTypeFolder  = "Folder"
+	nodeTypeAlbum   = "Album"
+)
+
+// Fields requested for nodes.
+const nodeFilter = "NodeID,Name,Type,UrlPath,Privacy,HasChildren,WebUri"
+
+// uriValue is a URI from a Uris object.  Depending on the verbosity, SmugMug
+// sends either the URI string or an object holding the URI.
+type uriValue string
+
+func (u *uriValue) UnmarshalJSON(data []byte) error {
+	var uri string
+	if err := json.Unmarshal(data, &uri); err == nil {
+		*u = uriValue(uri)
+		return nil
+	}
+
+	var obj uriJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*u = uriValue(obj.URI)
+	return nil
+}
+
+type nodeUrisJSON struct {
+	Album uriValue
+}
+
+type nodeJSON struct {
+	NodeID      string
+	Name        string
+	Type        string
+	URLPath     string
+	Privacy     string
+	HasChildren bool
+	WebURI      string
+	Uris        nodeUrisJSON
+}
+
+// albumKey returns the key of the album an album node refers to.
+func (n nodeJSON) albumKey() string {
+	if n.Uris.Album == "" {
+		return ""
+	}
+	return path.Base(string(n.Uris.Album))
+}
+
+type nodesJSON struct {
+	Node  []nodeJSON
+	Pages pagesJSON
+}
+
+// Top level response from a node's children URI.
+type nodesResponseJSON struct {
+	Response nodesJSON
+}
+
+type nodeEndpointJSON struct {
+	Node nodeJSON
+}
+
+// Top level response for a single node.
+type nodeResponseJSON struct {
+	Response nodeEndpointJSON
+}
+
+// treeNode is a node along with its children, once they are fetched.
+type treeNode struct {
+	Node     nodeJSON
+	Children []*treeNode
+}
+
+// fetchNodeChildren retrieves all the children of a node.  The remaining pages
+// are requested in parallel after the first.
+func fetchNodeChildren(client *http.Client, userToken *oauth.Credentials, nodeID string) ([]nodeJSON, error) {
+	uri := apiNode + "/" + nodeID + apiNodeChildren
+	first, err := getNodeChildrenPage(client, userToken, uri, 1, albumPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	starts := remainingPageStarts(first.Pages, albumPageSize)
+	pages := make([][]nodeJSON, len(starts))
+	err = getPages(starts, func(i int, start int) error {
+		page, err := getNodeChildrenPage(client, userToken, uri, start, albumPageSize)
+		pages[i] = page.Node
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]nodeJSON, 0, first.Pages.Total)
+	nodes = append(nodes, first.Node...)
+	for _, page := range pages {
+		nodes = append(nodes, page...)
+	}
+
+	return nodes, nil
+}
+
+// getNodeChildrenPage gets up to count children of a node starting at index
+// start.
+func getNodeChildrenPage(client *http.Client, userToken *oauth.Credentials,
+	uri string, start int, count int) (nodesJSON, error) {
+
+	var queryParams = url.Values{
+		"_filter":    {nodeFilter},
+		"_filteruri": {"Album"},
+		"start":      {fmt.Sprintf("%d", start)},
+		"count":      {fmt.Sprintf("%d", count)},
+	}
+
+	var respJSON nodesResponseJSON
+	if err := apiGet(client, userToken, uri, queryParams, &respJSON); err != nil {
+		return nodesJSON{}, fmt.Errorf("node children starting at %d: %v", start, err)
+	}
+
+	return respJSON.Response, nil
+}
+
+// walkNodes fetches the tree of folders and albums below root, one level at a
+// time, down to maxDepth levels (0 for no limit).  The children of all the
+// folders in a level are fetched in parallel.
+func walkNodes(client *http.Client, userToken *oauth.Credentials,
+	root *treeNode, maxDepth int) error {
+
+	level := []*treeNode{root}
+	for depth := 1; len(level) > 0 && (maxDepth == 0 || depth <= maxDepth); depth++ {
+		err := inParallel(len(level), func(i int) error {
+			nodes, err := fetchNodeChildren(client, userToken, level[i].Node.NodeID)
+			if err != nil {
+				return err
+			}
+			for _, node := range nodes {
+				level[i].Children = append(level[i].Children, &treeNode{Node: node})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		next := make([]*treeNode, 0, len(level))
+		for _, parent := range level {
+			for _, child := range parent.Children {
+				if child.Node.Type == nodeTypeFolder && child.Node.HasChildren {
+					next = append(next, child)
+				}
+			}
+		}
+		level = next
+	}
+
+	return nil
+}
+
+// printTree writes the children of node as an indented tree.  Albums show
+// their key, image count and privacy.
+func printTree(w io.Writer, node *treeNode, imageCounts map[string]int, indent string) {
+	for _, child := range node.Children {
+		switch child.Node.Type {
+		case nodeTypeFolder:
+			fmt.Fprintf(w, "%s%s/\n", indent, child.Node.Name)
+			printTree(w, child, imageCounts, indent+"  ")
+		case nodeTypeAlbum:
+			key := child.Node.albumKey()
+			fmt.Fprintf(w, "%s%s :: %s (%d images, %s)\n",
+				indent, child.Node.Name, key, imageCounts[key], child.Node.Privacy)
+		}
+	}
+}
+
+// tree prints the folders and albums below folderPath.
+func tree(folderPath string, maxDepth int) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	userURI, err := getUser(userToken)
+	if err != nil {
+		return
+	}
+
+	var client = http.Client{}
+	folder, err := ensureFolder(&client, userToken, path.Base(userURI), folderPath, false, "")
+	if err != nil {
+		log.Println("Error finding folder: " + err.Error())
+		return
+	}
+
+	// Nodes don't include image counts, so get them from the album list.
+	imageCountsChan := make(chan map[string]int, 1)
+	go func() {
+		imageCounts := make(map[string]int)
+		albums, err := fetchAlbums(&client, userToken, userURI, "AlbumKey,ImageCount")
+		if err != nil {
+			log.Println("Error getting image counts: " + err.Error())
+		}
+		for _, album := range albums {
+			imageCounts[album.AlbumKey] = album.ImageCount
+		}
+		imageCountsChan <- imageCounts
+	}()
+
+	root := &treeNode{Node: folder}
+	if err := walkNodes(&client, userToken, root, maxDepth); err != nil {
+		log.Println("Error getting folders: " + err.Error())
+		return
+	}
+
+	imageCounts := <-imageCountsChan
+	fmt.Println("/" + strings.Join(splitFolderPath(folderPath), "/"))
+	printTree(os.Stdout, root, imageCounts, "  ")
+}
+
+// moveNode moves a node into the folder node targetNodeID and returns the node
+// at its new location.
+func moveNode(client *http.Client, userToken *oauth.Credentials,
+	nodeID string, targetNodeID string) (nodeJSON, error) {
+
+	body := map[string]string{"MoveUris": "/api/v2/node/" + nodeID}
+	err := apiSend(client, userToken, "POST", apiNode+"/"+targetNodeID+apiMoveNodes, body, nil)
+	if err != nil {
+		return nodeJSON{}, err
+	}
+
+	var queryParams = url.Values{
+		"_filter":    {nodeFilter},
+		"_filteruri": {""},
+	}
+
+	var respJSON nodeResponseJSON
+	err = apiGet(client, userToken, apiNode+"/"+nodeID, queryParams, &respJSON)
+	return respJSON.Response.Node, err
+}
+
+// findTargetFolder returns the node ID of the folder that things are moved
+// into, creating the folder first if create is true.
+func findTargetFolder(client *http.Client, userToken *oauth.Credentials,
+	nickname string, folderPath string, create bool) (string, error) {
+
+	folder, err := ensureFolder(client, userToken, nickname, folderPath, create, "")
+	return folder.NodeID, err
+}
+
+// mvAlbum moves an album into the folder at folderPath.
+func mvAlbum(albumKey string, folderPath string, create bool) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	userURI, err := getUser(userToken)
+	if err != nil {
+		return
+	}
+
+	var client = http.Client{}
+	album, err := getAlbum(&client, userToken, albumKey)
+	if err != nil {
+		log.Println("Error getting album: " + err.Error())
+		return
+	}
+
+	targetNodeID, err := findTargetFolder(&client, userToken, path.Base(userURI), folderPath, create)
+	if err != nil {
+		log.Println("Error finding folder: " + err.Error())
+		return
+	}
+
+	node, err := moveNode(&client, userToken, album.NodeID, targetNodeID)
+	if err != nil {
+		log.Println("Error moving album: " + err.Error())
+		return
+	}
+
+	fmt.Println("Moved " + album.Name + " to " + node.WebURI)
+
+	db := openDB()
+	defer db.Close()
+
+	if isAlbumCached(db, albumKey) {
+		album.URLPath = node.URLPath
+		writeAlbumData(db, []albumJSON{album})
+	}
+}
+
+// mvFolder moves the folder at srcPath into the folder at destPath.
+func mvFolder(srcPath string, destPath string, create bool) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	userURI, err := getUser(userToken)
+	if err != nil {
+		return
+	}
+
+	var client = http.Client{}
+	nickname := path.Base(userURI)
+	src, err := ensureFolder(&client, userToken, nickname, srcPath, false, "")
+	if err != nil {
+		log.Println("Error finding folder " + srcPath + ": " + err.Error())
+		return
+	}
+
+	targetNodeID, err := findTargetFolder(&client, userToken, nickname, destPath, create)
+	if err != nil {
+		log.Println("Error finding folder " + destPath + ": " + err.Error())
+		return
+	}
+
+	node, err := moveNode(&client, userToken, src.NodeID, targetNodeID)
+	if err != nil {
+		log.Println("Error moving folder: " + err.Error())
+		return
+	}
+
+	fmt.Println("Moved " + srcPath + " to " + node.WebURI)
+
+	db := openDB()
+	defer db.Close()
+
+	moveAlbumFolderData(db, strings.Trim(src.URLPath, "/"), strings.Trim(node.URLPath, "/"))
+}
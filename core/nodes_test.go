@@ -0,0 +1,66 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestNodeAlbumKey(t *testing.T) {
+	responses := []string{
+		`{"Name": "Smith Wedding", "Uris": {"Album": "/api/v2/album/Xk3f9"}}`,
+		`{"Name": "Smith Wedding", "Uris": {"Album": {"Uri": "/api/v2/album/Xk3f9"}}}`,
+	}
+
+	for _, resp := range responses {
+		var node nodeJSON
+		if err := json.Unmarshal([]byte(resp), &node); err != nil {
+			t.Error(err)
+		}
+		if node.albumKey() != "Xk3f9" {
+			t.Errorf("expected: %s, actual: %s", "Xk3f9", node.albumKey())
+		}
+	}
+}
+
+func TestPrintTree(t *testing.T) {
+	album := func(name string, key string, privacy string) *treeNode {
+		return &treeNode{Node: nodeJSON{Name: name, Type: nodeTypeAlbum, Privacy: privacy,
+			Uris: nodeUrisJSON{Album: uriValue("/api/v2/album/" + key)}}}
+	}
+
+	root := &treeNode{Children: []*treeNode{
+		{Node: nodeJSON{Name: "Clients", Type: nodeTypeFolder}, Children: []*treeNode{
+			{Node: nodeJSON{Name: "2026", Type: nodeTypeFolder}, Children: []*treeNode{
+				album("Smith Wedding", "Xk3f9", "Private"),
+			}},
+		}},
+		album("Staging", "St4g3", "Unlisted"),
+	}}
+	imageCounts := map[string]int{"Xk3f9": 120, "St4g3": 7}
+
+	expected := "Clients/\n" +
+		"  2026/\n" +
+		"    Smith Wedding :: Xk3f9 (120 images, Private)\n" +
+		"Staging :: St4g3 (7 images, Unlisted)\n"
+
+	var out bytes.Buffer
+	printTree(&out, root, imageCounts, "")
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\nactual:\n%s", expected, out.String())
+	}
+}
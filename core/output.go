@@ -0,0 +1,260 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// Output formats for listing commands.
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatTSV  = "tsv"
+)
+
+// listFormat controls how listing commands such as albums and search print
+// their results.  If Template is set, it is used instead of Format.
+type listFormat struct {
+	Format   string
+	Template *template.Template
+}
+
+// albumRecord is an album as printed by listing commands.
+type albumRecord struct {
+	AlbumKey    string
+	Name        string
+	FolderPath  string
+	Description string
+	ImageCount  int
+	Privacy     string
+	LastUpdated string
+}
+
+// imageRecord is an image as printed by listing commands.
+type imageRecord struct {
+	ImageKey         string
+	AlbumKey         string
+	FileName         string
+	ArchivedMD5      string
+	ArchivedSize     int64
+	Caption          string
+	Keywords         []string
+	DateTimeUploaded string
+	Width            int
+	Height           int
+	Hidden           bool
+	WebURI           string
+}
+
+func toAlbumRecord(album albumJSON) albumRecord {
+	return albumRecord{
+		AlbumKey:    album.AlbumKey,
+		Name:        album.Name,
+		FolderPath:  albumFolderPath(album),
+		Description: album.Description,
+		ImageCount:  album.ImageCount,
+		Privacy:     album.Privacy,
+		LastUpdated: album.LastUpdated,
+	}
+}
+
+func toImageRecord(albumKey string, img albumImageJSON) imageRecord {
+	if albumKey == "" {
+		albumKey = img.albumKey()
+	}
+	return imageRecord{
+		ImageKey:         img.ImageKey,
+		AlbumKey:         albumKey,
+		FileName:         img.FileName,
+		ArchivedMD5:      img.ArchivedMD5,
+		ArchivedSize:     img.ArchivedSize,
+		Caption:          img.Caption,
+		Keywords:         img.KeywordArray,
+		DateTimeUploaded: img.DateTimeUploaded,
+		Width:            img.OriginalWidth,
+		Height:           img.OriginalHeight,
+		Hidden:           img.Hidden,
+		WebURI:           img.WebURI,
+	}
+}
+
+// newListFormat checks the -format and -template flags.  The template is
+// executed once for each record, with a newline after each.
+func newListFormat(format string, tmpl string) (listFormat, error) {
+	var f listFormat
+	if tmpl != "" {
+		t, err := template.New("record").Funcs(template.FuncMap{"join": strings.Join}).Parse(tmpl)
+		if err != nil {
+			return f, fmt.Errorf("bad -template: %v", err)
+		}
+		f.Template = t
+		return f, nil
+	}
+
+	f.Format = strings.ToLower(format)
+	switch f.Format {
+	case formatText, formatJSON, formatCSV, formatTSV:
+		return f, nil
+	}
+	return f, fmt.Errorf("-format must be %s, %s, %s or %s", formatText, formatJSON, formatCSV, formatTSV)
+}
+
+// structured returns true if the output is meant for other programs, so
+// prompts and progress messages must be left out.
+func (f listFormat) structured() bool {
+	return f.Template != nil || f.Format != formatText
+}
+
+// listWriter writes records in a listFormat.  Records may be written in
+// several batches, such as one per page of search results; close must be
+// called after the last batch.
+type listWriter struct {
+	format     listFormat
+	w          io.Writer
+	csv        *csv.Writer
+	recordType reflect.Type // Used for the CSV header.
+	count      int
+}
+
+// newWriter creates a listWriter for records of the same type as record.
+func (f listFormat) newWriter(w io.Writer, record interface{}) *listWriter {
+	lw := &listWriter{format: f, w: w, recordType: reflect.TypeOf(record)}
+	if f.Template == nil && (f.Format == formatCSV || f.Format == formatTSV) {
+		lw.csv = csv.NewWriter(w)
+		if f.Format == formatTSV {
+			lw.csv.Comma = '\t'
+		}
+	}
+	return lw
+}
+
+// write outputs records, which must be a slice of structs.  text formats the
+// record at index i for the text format.
+func (lw *listWriter) write(records interface{}, text func(i int) string) error {
+	rv := reflect.ValueOf(records)
+	for i := 0; i < rv.Len(); i++ {
+		record := rv.Index(i)
+		var err error
+		switch {
+		case lw.format.Template != nil:
+			if err = lw.format.Template.Execute(lw.w, record.Interface()); err == nil {
+				_, err = fmt.Fprintln(lw.w)
+			}
+		case lw.csv != nil:
+			if lw.count == 0 {
+				err = lw.csv.Write(recordFieldNames(lw.recordType))
+			}
+			if err == nil {
+				err = lw.csv.Write(recordFieldValues(record))
+			}
+		case lw.format.Format == formatJSON:
+			err = lw.writeJSON(record.Interface())
+		default:
+			_, err = fmt.Fprintln(lw.w, text(i))
+		}
+		if err != nil {
+			return err
+		}
+		lw.count++
+	}
+
+	if lw.csv != nil {
+		lw.csv.Flush()
+		return lw.csv.Error()
+	}
+	return nil
+}
+
+// writeJSON writes a record as an element of a JSON array.
+func (lw *listWriter) writeJSON(record interface{}) error {
+	bytes, err := json.MarshalIndent(record, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if lw.count == 0 {
+		sep = "[\n  "
+	}
+	_, err = fmt.Fprint(lw.w, sep+string(bytes))
+	return err
+}
+
+// close finishes the output.  A JSON array or CSV header is always written,
+// even if there were no records.
+func (lw *listWriter) close() error {
+	if lw.csv != nil && lw.count == 0 {
+		if err := lw.csv.Write(recordFieldNames(lw.recordType)); err != nil {
+			return err
+		}
+		lw.csv.Flush()
+		return lw.csv.Error()
+	}
+	if lw.format.Template != nil || lw.format.Format != formatJSON {
+		return nil
+	}
+
+	if lw.count == 0 {
+		_, err := fmt.Fprintln(lw.w, "[]")
+		return err
+	}
+	_, err := fmt.Fprintln(lw.w, "\n]")
+	return err
+}
+
+// writeList writes all the records at once.
+func (f listFormat) writeList(w io.Writer, records interface{}, text func(i int) string) error {
+	lw := f.newWriter(w, reflect.Zero(reflect.TypeOf(records).Elem()).Interface())
+	if err := lw.write(records, text); err != nil {
+		return err
+	}
+	return lw.close()
+}
+
+// recordFieldNames returns the names of a record's fields for a CSV header.
+func recordFieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, t.Field(i).Name)
+	}
+	return names
+}
+
+// recordFieldValues formats each of a record's fields for CSV.  Lists are
+// joined with semicolons.
+func recordFieldValues(record reflect.Value) []string {
+	values := make([]string, 0, record.NumField())
+	for i := 0; i < record.NumField(); i++ {
+		field := record.Field(i)
+		if field.Kind() == reflect.Slice {
+			items := make([]string, 0, field.Len())
+			for j := 0; j < field.Len(); j++ {
+				items = append(items, fmt.Sprint(field.Index(j).Interface()))
+			}
+			values = append(values, strings.Join(items, ";"))
+			continue
+		}
+		values = append(values, fmt.Sprint(field.Interface()))
+	}
+	return values
+}
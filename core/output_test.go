@@ -0,0 +1,132 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var outputTestRecords = []imageRecord{
+	{ImageKey: "key-1", AlbumKey: "album", FileName: "a: b.jpg", Keywords: []string{"beach", "sun"}},
+	{ImageKey: "key-2", AlbumKey: "album", FileName: "c.jpg"},
+}
+
+func writeTestRecords(t *testing.T, format string, tmpl string) string {
+	f, err := newListFormat(format, tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	lw := f.newWriter(&buf, imageRecord{})
+	// Written in two batches, like pages of search results.
+	if err := lw.write(outputTestRecords[:1], func(i int) string { return "first" }); err != nil {
+		t.Error(err)
+	}
+	if err := lw.write(outputTestRecords[1:], func(i int) string { return "second" }); err != nil {
+		t.Error(err)
+	}
+	if err := lw.close(); err != nil {
+		t.Error(err)
+	}
+	return buf.String()
+}
+
+func TestWriteText(t *testing.T) {
+	expected := "first\nsecond\n"
+	actual := writeTestRecords(t, "text", "")
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var actual []imageRecord
+	if err := json.Unmarshal([]byte(writeTestRecords(t, "json", "")), &actual); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(outputTestRecords, actual) {
+		t.Errorf("expected: %v, actual: %v", outputTestRecords, actual)
+	}
+
+	var buf bytes.Buffer
+	f, _ := newListFormat("json", "")
+	f.writeList(&buf, []imageRecord{}, nil)
+	if buf.String() != "[]\n" {
+		t.Errorf("expected: [], actual: %s", buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	expected := "ImageKey,AlbumKey,FileName,ArchivedMD5,ArchivedSize,Caption,Keywords,DateTimeUploaded,Width,Height,Hidden,WebURI\n" +
+		"key-1,album,a: b.jpg,,0,,beach;sun,,0,0,false,\n" +
+		"key-2,album,c.jpg,,0,,,,0,0,false,\n"
+	actual := writeTestRecords(t, "CSV", "")
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestWriteCSVEmpty(t *testing.T) {
+	expected := "AlbumKey,Name,FolderPath,Description,ImageCount,Privacy,LastUpdated\n"
+
+	var buf bytes.Buffer
+	f, _ := newListFormat("csv", "")
+	lw := f.newWriter(&buf, albumRecord{})
+	if err := lw.close(); err != nil {
+		t.Error(err)
+	}
+	if expected != buf.String() {
+		t.Errorf("expected: %s, actual: %s", expected, buf.String())
+	}
+
+	buf.Reset()
+	f.writeList(&buf, []albumRecord{}, nil)
+	if expected != buf.String() {
+		t.Errorf("expected: %s, actual: %s", expected, buf.String())
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	expected := "ImageKey\tAlbumKey\tFileName\tArchivedMD5\tArchivedSize\tCaption\tKeywords\tDateTimeUploaded\tWidth\tHeight\tHidden\tWebURI\n" +
+		"key-1\talbum\ta: b.jpg\t\t0\t\tbeach;sun\t\t0\t0\tfalse\t\n" +
+		"key-2\talbum\tc.jpg\t\t0\t\t\t\t0\t0\tfalse\t\n"
+	actual := writeTestRecords(t, "tsv", "")
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	expected := "key-1=a: b.jpg [beach,sun]\nkey-2=c.jpg []\n"
+	actual := writeTestRecords(t, "", `{{.ImageKey}}={{.FileName}} [{{join .Keywords ","}}]`)
+	if expected != actual {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestBadListFormat(t *testing.T) {
+	if _, err := newListFormat("xml", ""); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+
+	if _, err := newListFormat("text", "{{.Name"); err == nil {
+		t.Error("Expected error for bad template")
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pruneCandidate is an image old enough to prune, along with where another
+// copy of it is kept.  Images with no other copy are never deleted.
+type pruneCandidate struct {
+	Image albumImageJSON
+	Copy  string // Describes the other copy, empty if there is none.
+}
+
+// parseAge accepts an age in days (90d) or weeks (12w), or any duration
+// understood by time.ParseDuration (36h).
+func parseAge(age string) (time.Duration, error) {
+	age = strings.TrimSpace(age)
+	if n := len(age); n > 1 && (age[n-1] == 'd' || age[n-1] == 'w') {
+		count, err := strconv.Atoi(age[:n-1])
+		if err == nil && count >= 0 {
+			day := 24 * time.Hour
+			if age[n-1] == 'w' {
+				day *= 7
+			}
+			return time.Duration(count) * day, nil
+		}
+	}
+
+	d, err := time.ParseDuration(age)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("bad age %s, use a number of days like 90d", age)
+	}
+	return d, nil
+}
+
+// findCopies looks for another copy of each image: in another album, in a
+// file saved by backup, or in the local files, which are keyed by hash.  The
+// image table may be out of date, so albums it lists are only trusted once
+// the images fetched by fetchImages confirm they still hold the image.  Each
+// album is fetched at most once.  An image copied to another album keeps its
+// image key and is deleted along with the original, so only images with a
+// different key count as copies.  Backed up files must still match the hash.
+func findCopies(db *sql.DB, albumKey string, images []albumImageJSON,
+	localFiles map[string]string, fetchImages func(albumKey string) ([]albumImageJSON, error)) []pruneCandidate {
+
+	// Image keys in each album, keyed by hash.
+	albumHashes := make(map[string]map[string][]string)
+	inAlbum := func(otherKey string, img albumImageJSON) bool {
+		hashes, fetched := albumHashes[otherKey]
+		if !fetched {
+			otherImages, err := fetchImages(otherKey)
+			if err != nil {
+				log.Println("Error getting images of album " + otherKey + ": " + err.Error())
+			}
+			hashes = make(map[string][]string, len(otherImages))
+			for _, other := range otherImages {
+				hashes[other.ArchivedMD5] = append(hashes[other.ArchivedMD5], other.ImageKey)
+			}
+			albumHashes[otherKey] = hashes
+		}
+		for _, imageKey := range hashes[img.ArchivedMD5] {
+			if imageKey != img.ImageKey {
+				return true
+			}
+		}
+		return false
+	}
+
+	candidates := make([]pruneCandidate, 0, len(images))
+	for _, img := range images {
+		candidate := pruneCandidate{Image: img}
+		albums := make([]string, 0, 1)
+		for _, otherKey := range getOtherAlbumsWithImage(db, albumKey, img.ArchivedMD5) {
+			if inAlbum(otherKey, img) {
+				albums = append(albums, otherKey)
+			}
+		}
+
+		if len(albums) > 0 {
+			candidate.Copy = "in album " + strings.Join(albums, ", ")
+		} else if path, found := localFiles[img.ArchivedMD5]; found {
+			candidate.Copy = "at " + path
+		} else {
+			for _, path := range getBackedUpPaths(db, img.ArchivedMD5) {
+				if hash, _, err := calcMD5(path); err == nil && hash == img.ArchivedMD5 {
+					candidate.Copy = "backed up at " + path
+					break
+				}
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// pruneAlbum deletes the images picked by the selector from a staging album,
+// but only those that have another copy.  If dryRun is true, the images are
+// only listed.
+func pruneAlbum(albumKey string, sel imageSelector, backupDir string, dryRun bool, skipConfirm bool) {
+	localFiles := make(map[string]string)
+	if backupDir != "" {
+		files, err := localMediaFiles(backupDir)
+		if err != nil {
+			log.Println("Error reading " + backupDir + ": " + err.Error())
+			return
+		}
+		for _, file := range files {
+			localFiles[file.Hash] = file.Path
+		}
+	}
+
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, albumKey, imageSelectFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	selected := selectImages(images, sel)
+	if len(selected) == 0 {
+		fmt.Println("No images old enough to prune.")
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	safe := make([]albumImageJSON, 0, len(selected))
+	// Other albums are fetched again, which also brings their rows up to date.
+	fetchImages := func(otherKey string) ([]albumImageJSON, error) {
+		return refreshAlbumImages(&client, userToken, db, otherKey, imageSyncFilter)
+	}
+	for _, candidate := range findCopies(db, albumKey, selected, localFiles, fetchImages) {
+		if candidate.Copy == "" {
+			fmt.Println("Keeping " + candidate.Image.FileName + " :: " + candidate.Image.ImageKey + ", no other copy found")
+			continue
+		}
+		fmt.Println("Can delete " + candidate.Image.FileName + " :: " + candidate.Image.ImageKey + ", " + candidate.Copy)
+		safe = append(safe, candidate.Image)
+	}
+
+	fmt.Printf("\n%d images old enough to prune: %d have another copy, %d have none and will be kept.\n",
+		len(selected), len(safe), len(selected)-len(safe))
+
+	if dryRun || len(safe) == 0 {
+		return
+	}
+
+	question := fmt.Sprintf("Delete %d images from album %s?", len(safe), albumKey)
+	if !skipConfirm && !confirm(question) {
+		return
+	}
+
+	deleted := deleteAlbumImages(&client, userToken, db, albumKey, safe)
+	fmt.Printf("Deleted %d images.\n", deleted)
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+	}
+
+	for age, expected := range tests {
+		actual, err := parseAge(age)
+		if err != nil {
+			t.Error(err)
+		}
+		if expected != actual {
+			t.Errorf("age: %s, expected: %s, actual: %s", age, expected, actual)
+		}
+	}
+
+	for _, age := range []string{"", "d", "-5d", "soon"} {
+		if _, err := parseAge(age); err == nil {
+			t.Errorf("Expected error for age %q", age)
+		}
+	}
+}
+
+func TestFindCopies(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	writeTestImageData(db, "staging", []imageJSON{{"hash-1", "milk.jpg"}, {"hash-2", "rose.jpg"}})
+	writeTestImageData(db, "client", []imageJSON{{"hash-1", "milk.jpg"}, {"hash-6", "leaf.jpg"}})
+
+	backedUp := filepath.Join(testDir, "backed-up.jpg")
+	if err := ioutil.WriteFile(backedUp, []byte("image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(backedUp)
+	backedUpHash := fmt.Sprintf("%x", md5.Sum([]byte("image")))
+	writeBackedUpImage(db, "staging", "key-3", backedUpHash, backedUp)
+	writeBackedUpImage(db, "staging", "key-4", "hash-4", filepath.Join(testDir, "removed.jpg"))
+	// The backed up file was changed since it was saved.
+	writeBackedUpImage(db, "staging", "key-7", "hash-7", backedUp)
+
+	images := []albumImageJSON{
+		{ImageKey: "key-1", ArchivedMD5: "hash-1"},
+		{ImageKey: "key-2", ArchivedMD5: "hash-2"},
+		{ImageKey: "key-3", ArchivedMD5: backedUpHash},
+		{ImageKey: "key-4", ArchivedMD5: "hash-4"},
+		{ImageKey: "key-5", ArchivedMD5: "hash-5"},
+		{ImageKey: "key-6", ArchivedMD5: "hash-6"},
+		{ImageKey: "key-7", ArchivedMD5: "hash-7"},
+	}
+	localFiles := map[string]string{"hash-5": "/backups/orange.jpg"}
+
+	expected := []string{"in album client", "", "backed up at " + backedUp, "", "at /backups/orange.jpg", "", ""}
+	fetchImages := func(albumKey string) ([]albumImageJSON, error) {
+		return []albumImageJSON{
+			{ImageKey: "key-9", ArchivedMD5: "hash-1"},
+			// Copied with the copy command, so it's the same image.
+			{ImageKey: "key-6", ArchivedMD5: "hash-6"},
+		}, nil
+	}
+	candidates := findCopies(db, "staging", images, localFiles, fetchImages)
+	if len(candidates) != len(expected) {
+		t.Fatalf("Expected %d candidates but found %d", len(expected), len(candidates))
+	}
+
+	for i, candidate := range candidates {
+		if candidate.Copy != expected[i] {
+			t.Errorf("%s expected: %q, actual: %q", candidate.Image.ImageKey, expected[i], candidate.Copy)
+		}
+	}
+}
+
+func TestFindCopiesStaleAlbum(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	writeTestImageData(db, "stale", []imageJSON{{"hash-1", "milk.jpg"}})
+	writeTestImageData(db, "gone", []imageJSON{{"hash-2", "rose.jpg"}})
+
+	fetched := make(map[string]int)
+	fetchImages := func(albumKey string) ([]albumImageJSON, error) {
+		fetched[albumKey]++
+		if albumKey == "gone" {
+			return nil, errors.New("album not found")
+		}
+		// The image was deleted from the album after its rows were saved.
+		return []albumImageJSON{{ImageKey: "key-9", ArchivedMD5: "hash-9"}}, nil
+	}
+
+	images := []albumImageJSON{
+		{ImageKey: "key-1", ArchivedMD5: "hash-1"},
+		{ImageKey: "key-2", ArchivedMD5: "hash-2"},
+		{ImageKey: "key-3", ArchivedMD5: "hash-1"},
+	}
+	for _, candidate := range findCopies(db, "staging", images, nil, fetchImages) {
+		if candidate.Copy != "" {
+			t.Errorf("%s expected no copy, actual: %q", candidate.Image.ImageKey, candidate.Copy)
+		}
+	}
+
+	if fetched["stale"] != 1 || fetched["gone"] != 1 {
+		t.Errorf("expected each album fetched once, actual: %v", fetched)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestManifestNewlines(t *testing.T) {
+	manifest := "milk.jpg\norange.png\r\n\nstar.png\n"
+	expected := []string{"milk.jpg", "orange.png", "star.png"}
+	actual, err := parseManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestManifestNul(t *testing.T) {
+	manifest := "milk.jpg\x00my\norange.png\x00star.png\x00"
+	expected := []string{"milk.jpg", "my\norange.png", "star.png"}
+	actual, err := parseManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestUniqueFileNames(t *testing.T) {
+	filenames := []string{"see.png", "face.jpg", "see.png", "orange.jpg", "face.jpg"}
+	expected := []string{"see.png", "face.jpg", "orange.jpg"}
+	actual := uniqueFileNames(filenames)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestExistingFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "smuggo-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Brackets would be a pattern if the name were globbed.
+	photo := filepath.Join(dir, "photo[1].jpg")
+	if err := ioutil.WriteFile(photo, []byte("milk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{photo}
+	actual := existingFiles([]string{photo, filepath.Join(dir, "missing.jpg")})
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// removeImages deletes the images picked by the selector from an album after
+// asking for confirmation.
+func removeImages(albumKey string, sel imageSelector, skipConfirm bool) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	images, err := fetchAlbumImages(&client, userToken, albumKey, imageSelectFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	selected := selectImages(images, sel)
+	if len(selected) == 0 {
+		fmt.Println("No matching images.")
+		return
+	}
+
+	for _, img := range selected {
+		fmt.Println(img.FileName + " :: " + img.ImageKey)
+	}
+
+	question := fmt.Sprintf("Delete %d of %d images from album %s?", len(selected), len(images), albumKey)
+	if !skipConfirm && !confirm(question) {
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	deleted := deleteAlbumImages(&client, userToken, db, albumKey, selected)
+	fmt.Printf("Deleted %d images.\n", deleted)
+}
+
+// deleteAlbumImages deletes the given images from the album on SmugMug and
+// removes them from the image table.  Returns the number of images deleted.
+func deleteAlbumImages(client *http.Client, userToken *oauth.Credentials,
+	db *sql.DB, albumKey string, images []albumImageJSON) int {
+
+	deleted := 0
+	for _, img := range images {
+		err := apiSend(client, userToken, "DELETE", apiRoot+img.URI, nil, nil)
+		if err != nil {
+			log.Println("Error deleting " + img.FileName + ": " + err.Error())
+			continue
+		}
+
+		removeImageData(db, albumKey, img)
+		fmt.Println("Deleted " + img.FileName)
+		deleted++
+	}
+
+	return deleted
+}
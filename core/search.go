@@ -0,0 +1,181 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+const (
+	apiImage     = apiRoot + "/api/v2/image"
+	searchImages = apiImage + "!search"
+)
+
+// Number of search results shown before asking to show more.
+const searchPageSize = 15
+
+// Fields requested for images found by a search.
+const imageSearchFilter = "ImageKey,FileName,Caption,KeywordArray,DateTimeUploaded"
+
+type imageSearchJSON struct {
+	Image []albumImageJSON
+	Pages pagesJSON
+}
+
+// Top level response for image search from SmugMug API.
+type imageSearchResponseJSON struct {
+	Response imageSearchJSON
+}
+
+// albumKey returns the key of the album an image belongs to, if the album's
+// URI was requested.
+func (img albumImageJSON) albumKey() string {
+	if img.Uris.ImageAlbum == "" {
+		return ""
+	}
+	return path.Base(string(img.Uris.ImageAlbum))
+}
+
+// searchPages shows pages of search results until limit results are shown
+// or there are no more results.  With a limit of 0, all results are shown if
+// all is true and otherwise the user is asked before each new page is shown.
+// getPage fetches and shows at most count results beginning at start.  It
+// returns the number it showed and the paging info of the response.
+func searchPages(limit int, all bool,
+	getPage func(start int, count int) (int, pagesJSON, error)) (int, error) {
+
+	shown := 0
+	start := 1
+	for {
+		count := searchPageSize
+		if limit > 0 || all {
+			count = albumPageSize
+		}
+		if limit > 0 && limit-shown < count {
+			count = limit - shown
+		}
+
+		n, pages, err := getPage(start, count)
+		if err != nil {
+			return shown, err
+		}
+		shown += n
+
+		start = pages.Start + pages.Count
+		if pages.Count == 0 || start > pages.Total || (limit > 0 && shown >= limit) {
+			return shown, nil
+		}
+
+		if limit == 0 && !all && n > 0 {
+			fmt.Println("Press Enter for more results or Ctrl-C to quit.")
+			var foo string
+			fmt.Scanln(&foo)
+		}
+	}
+}
+
+// getImageSearchPage searches the user's images for query and returns up to
+// count results beginning at index start.
+func getImageSearchPage(client *http.Client, userToken *oauth.Credentials,
+	userURI string, query string, start int, count int) (imageSearchJSON, error) {
+
+	var queryParams = url.Values{
+		"_filter":       {imageSearchFilter},
+		"_filteruri":    {"ImageAlbum"},
+		"Scope":         {userURI},
+		"SortDirection": {"Descending"},
+		"SortMethod":    {"Rank"},
+		"Text":          {query},
+		"start":         {fmt.Sprintf("%d", start)},
+		"count":         {fmt.Sprintf("%d", count)},
+	}
+
+	var respJSON imageSearchResponseJSON
+	if err := apiGet(client, userToken, searchImages, queryParams, &respJSON); err != nil {
+		return imageSearchJSON{}, fmt.Errorf("image search starting at %d: %v", start, err)
+	}
+
+	return respJSON.Response, nil
+}
+
+// printImageSearchResults outputs the images with lw.  The text format shows
+// the filename, image key and album key of each image.
+func printImageSearchResults(lw *listWriter, images []albumImageJSON) error {
+	records := make([]imageRecord, 0, len(images))
+	for _, img := range images {
+		records = append(records, toImageRecord("", img))
+	}
+
+	return lw.write(records, func(i int) string {
+		return records[i].FileName + " :: " + records[i].ImageKey + " :: " + records[i].AlbumKey
+	})
+}
+
+// searchImagesCmd searches the user's images with SmugMug's image search.
+// SmugMug matches the terms against filenames, titles, captions and
+// keywords.  Results are further narrowed by the selector, which picks images
+// by keyword and upload date.
+func searchImagesCmd(terms []string, sel imageSelector, limit int, all bool, format listFormat) {
+	if sel.Keyword != "" {
+		terms = append(terms, sel.Keyword)
+	}
+	if len(terms) == 0 {
+		log.Println("Error searching images: no search terms or -keyword given")
+		return
+	}
+
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	userURI, err := getUser(userToken)
+	if err != nil {
+		return
+	}
+
+	var client = http.Client{}
+	query := strings.Join(terms, " ")
+	lw := format.newWriter(os.Stdout, imageRecord{})
+	defer lw.close()
+
+	all = all || format.structured()
+	shown, err := searchPages(limit, all, func(start int, count int) (int, pagesJSON, error) {
+		page, err := getImageSearchPage(&client, userToken, userURI, query, start, count)
+		if err != nil {
+			return 0, page.Pages, err
+		}
+
+		images := selectImages(page.Image, sel)
+		return len(images), page.Pages, printImageSearchResults(lw, images)
+	})
+	if err != nil {
+		log.Println("Error searching images: " + err.Error())
+		return
+	}
+
+	if shown == 0 && !format.structured() {
+		fmt.Println("No search results found.")
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeSearchPages serves total results in pages of at most count results and
+// records the start and count of each request.
+func fakeSearchPages(total int, requests *[][2]int) func(int, int) (int, pagesJSON, error) {
+	return func(start int, count int) (int, pagesJSON, error) {
+		*requests = append(*requests, [2]int{start, count})
+		n := count
+		if start+n-1 > total {
+			n = total - start + 1
+		}
+		return n, pagesJSON{Total: total, Start: start, Count: n}, nil
+	}
+}
+
+func TestSearchPagesLimit(t *testing.T) {
+	var requests [][2]int
+	shown, err := searchPages(150, false, fakeSearchPages(500, &requests))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if shown != 150 {
+		t.Errorf("expected: 150, actual: %d", shown)
+	}
+
+	expected := [][2]int{{1, 100}, {101, 50}}
+	if !reflect.DeepEqual(expected, requests) {
+		t.Errorf("expected: %v, actual: %v", expected, requests)
+	}
+}
+
+func TestSearchPagesAll(t *testing.T) {
+	var requests [][2]int
+	shown, err := searchPages(0, true, fakeSearchPages(250, &requests))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if shown != 250 {
+		t.Errorf("expected: 250, actual: %d", shown)
+	}
+
+	expected := [][2]int{{1, 100}, {101, 100}, {201, 100}}
+	if !reflect.DeepEqual(expected, requests) {
+		t.Errorf("expected: %v, actual: %v", expected, requests)
+	}
+}
+
+func TestImageAlbumKey(t *testing.T) {
+	img := albumImageJSON{Uris: imageUrisJSON{ImageAlbum: "/api/v2/album/abc123"}}
+	if img.albumKey() != "abc123" {
+		t.Errorf("expected: abc123, actual: %s", img.albumKey())
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Fields requested for images that are picked with selectors.
+const imageSelectFilter = imageHashFilter + ",Uri,DateTimeUploaded,KeywordArray"
+
+// imageSelector picks images from an album.  Every selector that is set must
+// match for an image to be picked.
+type imageSelector struct {
+	Name    string    // Filename pattern, as used by path.Match.
+	MD5     string    // Hash of the archived original.
+	Before  time.Time // Uploaded before this time.
+	After   time.Time // Uploaded at or after this time.
+	Keyword string    // Has this keyword, ignoring case.
+	All     bool      // Pick every image.
+}
+
+// newImageSelector builds a selector from the selector flags.
+func newImageSelector(name string, md5 string, before string, after string,
+	keyword string, all bool) (imageSelector, error) {
+
+	sel := imageSelector{Name: name, MD5: strings.ToLower(md5), Keyword: keyword, All: all}
+
+	if name != "" {
+		if _, err := path.Match(name, ""); err != nil {
+			return sel, fmt.Errorf("bad -name pattern %s: %v", name, err)
+		}
+	}
+
+	if before != "" {
+		t, err := parseDate(before)
+		if err != nil {
+			return sel, err
+		}
+		sel.Before = t
+	}
+
+	if after != "" {
+		t, err := parseDate(after)
+		if err != nil {
+			return sel, err
+		}
+		sel.After = t
+	}
+
+	if sel.empty() {
+		return sel, errors.New("no images selected, use -name, -md5, -before, -after, -keyword or -all")
+	}
+
+	return sel, nil
+}
+
+// parseDate accepts either a date (2006-01-02) in local time or an RFC 3339
+// timestamp.
+func parseDate(date string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", date, time.Local); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return t, fmt.Errorf("bad date %s, use YYYY-MM-DD or RFC 3339", date)
+	}
+
+	return t, nil
+}
+
+// empty returns true if no selectors are set.
+func (s imageSelector) empty() bool {
+	return !s.All && s.Name == "" && s.MD5 == "" && s.Before.IsZero() &&
+		s.After.IsZero() && s.Keyword == ""
+}
+
+// matches returns true if the image is picked by the selector.
+func (s imageSelector) matches(img albumImageJSON) bool {
+	if s.empty() {
+		return false
+	}
+
+	if s.Name != "" {
+		if matched, _ := path.Match(s.Name, img.FileName); !matched {
+			return false
+		}
+	}
+
+	if s.MD5 != "" && s.MD5 != img.ArchivedMD5 {
+		return false
+	}
+
+	if !s.Before.IsZero() || !s.After.IsZero() {
+		uploaded, err := time.Parse(time.RFC3339, img.DateTimeUploaded)
+		if err != nil {
+			return false
+		}
+		if !s.Before.IsZero() && !uploaded.Before(s.Before) {
+			return false
+		}
+		if !s.After.IsZero() && uploaded.Before(s.After) {
+			return false
+		}
+	}
+
+	if s.Keyword != "" && !hasKeyword(img.KeywordArray, s.Keyword) {
+		return false
+	}
+
+	return true
+}
+
+// hasKeyword returns true if keyword is one of keywords, ignoring case.
+func hasKeyword(keywords []string, keyword string) bool {
+	for _, k := range keywords {
+		if strings.EqualFold(strings.TrimSpace(k), strings.TrimSpace(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectImages returns the images picked by the selector.
+func selectImages(images []albumImageJSON, sel imageSelector) []albumImageJSON {
+	selected := make([]albumImageJSON, 0, len(images))
+	for _, img := range images {
+		if sel.matches(img) {
+			selected = append(selected, img)
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,118 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+var selectorTestImages = []albumImageJSON{
+	{ImageKey: "key-1", FileName: "milk.jpg", ArchivedMD5: "hash-1", DateTimeUploaded: "2026-01-05T10:00:00+00:00"},
+	{ImageKey: "key-2", FileName: "orange.png", ArchivedMD5: "hash-2", DateTimeUploaded: "2026-03-05T10:00:00+00:00",
+		KeywordArray: []string{"Fruit"}},
+	{ImageKey: "key-3", FileName: "rose.jpg", ArchivedMD5: "hash-3", DateTimeUploaded: "2026-06-05T10:00:00+00:00",
+		KeywordArray: []string{"flower", "fruit"}},
+}
+
+func selectedKeys(images []albumImageJSON) []string {
+	keys := make([]string, 0, len(images))
+	for _, img := range images {
+		keys = append(keys, img.ImageKey)
+	}
+	return keys
+}
+
+func TestSelectByName(t *testing.T) {
+	sel, err := newImageSelector("*.jpg", "", "", "", "", false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"key-1", "key-3"}
+	actual := selectedKeys(selectImages(selectorTestImages, sel))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestSelectByMD5(t *testing.T) {
+	sel, err := newImageSelector("", "HASH-2", "", "", "", false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"key-2"}
+	actual := selectedKeys(selectImages(selectorTestImages, sel))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestSelectBeforeAndName(t *testing.T) {
+	sel, err := newImageSelector("*.jpg", "", "2026-04-01", "", "", false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"key-1"}
+	actual := selectedKeys(selectImages(selectorTestImages, sel))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestSelectAfterAndKeyword(t *testing.T) {
+	sel, err := newImageSelector("", "", "", "2026-02-01", "fruit", false)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"key-2", "key-3"}
+	actual := selectedKeys(selectImages(selectorTestImages, sel))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+
+	sel, _ = newImageSelector("", "", "2026-04-01", "2026-02-01", "fruit", false)
+	expected = []string{"key-2"}
+	actual = selectedKeys(selectImages(selectorTestImages, sel))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestSelectAll(t *testing.T) {
+	sel, err := newImageSelector("", "", "", "", "", true)
+	if err != nil {
+		t.Error(err)
+	}
+
+	expected := []string{"key-1", "key-2", "key-3"}
+	actual := selectedKeys(selectImages(selectorTestImages, sel))
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %s, actual: %s", expected, actual)
+	}
+}
+
+func TestSelectNothing(t *testing.T) {
+	if _, err := newImageSelector("", "", "", "", "", false); err == nil {
+		t.Error("Expected error when no selectors given")
+	}
+
+	if _, err := newImageSelector("", "", "last week", "", "", false); err == nil {
+		t.Error("Expected error for bad date")
+	}
+}
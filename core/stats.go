@@ -0,0 +1,160 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fields requested for images when calculating album stats.
+const imageStatsFilter = imageHashFilter + ",ArchivedSize,DateTimeUploaded"
+
+// albumStats summarizes the images in an album.
+type albumStats struct {
+	ImageCount   int
+	TotalSize    int64
+	FileTypes    map[string]int // Number of images for each lowercase extension.
+	Earliest     time.Time      // Earliest upload date.
+	Latest       time.Time      // Latest upload date.
+	BySmuggo     int            // Images uploaded by smuggo.
+	OtherUploads int            // Images added some other way.
+}
+
+// calcAlbumStats summarizes the images of an album.  Images are counted as
+// uploaded by smuggo if their key matches one of smuggo's upload records for
+// the album, or their hash matches a record without a key.  Records made
+// before keys were saved have none.
+func calcAlbumStats(images []albumImageJSON, uploads []uploadRecord) albumStats {
+	uploadedKeys := make(map[string]bool, len(uploads))
+	uploadedHashes := make(map[string]bool, len(uploads))
+	for _, upload := range uploads {
+		if upload.ImageKey != "" {
+			uploadedKeys[upload.ImageKey] = true
+		} else {
+			uploadedHashes[upload.Hash] = true
+		}
+	}
+
+	stats := albumStats{ImageCount: len(images), FileTypes: make(map[string]int)}
+	for _, img := range images {
+		stats.TotalSize += img.ArchivedSize
+
+		ext := strings.ToLower(strings.TrimPrefix(path.Ext(img.FileName), "."))
+		if ext == "" {
+			ext = "(none)"
+		}
+		stats.FileTypes[ext]++
+
+		if uploaded, err := time.Parse(time.RFC3339, img.DateTimeUploaded); err == nil {
+			if stats.Earliest.IsZero() || uploaded.Before(stats.Earliest) {
+				stats.Earliest = uploaded
+			}
+			if uploaded.After(stats.Latest) {
+				stats.Latest = uploaded
+			}
+		}
+
+		if uploadedKeys[img.ImageKey] || uploadedHashes[img.ArchivedMD5] {
+			stats.BySmuggo++
+		} else {
+			stats.OtherUploads++
+		}
+	}
+
+	return stats
+}
+
+// formatSize shows a number of bytes in the largest unit that keeps the
+// number at least 1.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// printAlbumStats writes the stats in a human readable form.
+func printAlbumStats(w io.Writer, stats albumStats) {
+	fmt.Fprintf(w, "Images: %d\n", stats.ImageCount)
+	fmt.Fprintf(w, "Total size: %s (%d bytes)\n", formatSize(stats.TotalSize), stats.TotalSize)
+	if !stats.Earliest.IsZero() {
+		fmt.Fprintf(w, "Earliest upload: %s\n", stats.Earliest.Local().Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(w, "Latest upload: %s\n", stats.Latest.Local().Format("2006-01-02 15:04:05"))
+	}
+
+	exts := make([]string, 0, len(stats.FileTypes))
+	for ext := range stats.FileTypes {
+		exts = append(exts, ext)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if stats.FileTypes[exts[i]] != stats.FileTypes[exts[j]] {
+			return stats.FileTypes[exts[i]] > stats.FileTypes[exts[j]]
+		}
+		return exts[i] < exts[j]
+	})
+
+	if len(exts) > 0 {
+		fmt.Fprintln(w, "File types:")
+		for _, ext := range exts {
+			fmt.Fprintf(w, "\t%s: %d\n", ext, stats.FileTypes[ext])
+		}
+	}
+
+	fmt.Fprintf(w, "Uploaded by smuggo: %d\n", stats.BySmuggo)
+	fmt.Fprintf(w, "Uploaded other ways: %d\n", stats.OtherUploads)
+}
+
+// albumStatsCmd prints a summary of an album's images.
+func albumStatsCmd(albumKey string) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	album, err := getAlbum(&client, userToken, albumKey)
+	if err != nil {
+		log.Println("Error getting album: " + err.Error())
+		return
+	}
+
+	images, err := fetchAlbumImages(&client, userToken, albumKey, imageStatsFilter)
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	fmt.Println(album.Name + " :: " + album.AlbumKey)
+	printAlbumStats(os.Stdout, calcAlbumStats(images, getUploads(db, albumKey)))
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalcAlbumStats(t *testing.T) {
+	images := []albumImageJSON{
+		{ImageKey: "key-1", FileName: "milk.JPG", ArchivedMD5: "hash-1", ArchivedSize: 1000,
+			DateTimeUploaded: "2026-03-05T10:00:00+00:00"},
+		{ImageKey: "key-2", FileName: "orange.jpg", ArchivedMD5: "hash-2", ArchivedSize: 2000,
+			DateTimeUploaded: "2026-01-05T10:00:00+00:00"},
+		{ImageKey: "key-3", FileName: "clip.mp4", ArchivedMD5: "hash-3", ArchivedSize: 5000,
+			DateTimeUploaded: "2026-06-05T10:00:00+00:00"},
+	}
+	uploads := []uploadRecord{
+		{ImageKey: "key-1", Hash: "hash-1"},
+		{Hash: "hash-3"},
+		// The same file uploaded again by other means isn't counted.
+		{ImageKey: "key-9", Hash: "hash-2"},
+	}
+
+	stats := calcAlbumStats(images, uploads)
+
+	if stats.ImageCount != 3 || stats.TotalSize != 8000 {
+		t.Errorf("expected: 3 images, 8000 bytes, actual: %d images, %d bytes", stats.ImageCount, stats.TotalSize)
+	}
+
+	expTypes := map[string]int{"jpg": 2, "mp4": 1}
+	if !reflect.DeepEqual(expTypes, stats.FileTypes) {
+		t.Errorf("expected: %v, actual: %v", expTypes, stats.FileTypes)
+	}
+
+	if stats.Earliest.Format("2006-01-02") != "2026-01-05" || stats.Latest.Format("2006-01-02") != "2026-06-05" {
+		t.Errorf("expected: 2026-01-05 to 2026-06-05, actual: %s to %s", stats.Earliest, stats.Latest)
+	}
+
+	if stats.BySmuggo != 2 || stats.OtherUploads != 1 {
+		t.Errorf("expected: 2 by smuggo, 1 other, actual: %d by smuggo, %d other", stats.BySmuggo, stats.OtherUploads)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:        "512 B",
+		2048:       "2.0 KiB",
+		1572864:    "1.5 MiB",
+		3221225472: "3.0 GiB",
+	}
+
+	for size, expected := range tests {
+		actual := formatSize(size)
+		if expected != actual {
+			t.Errorf("size: %d, expected: %s, actual: %s", size, expected, actual)
+		}
+	}
+}
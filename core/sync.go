@@ -0,0 +1,159 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localFile is a file in a local directory along with its MD5 hash.
+type localFile struct {
+	Path string
+	Hash string
+}
+
+// isMediaFile returns true if the file extension is for an image or video.
+func isMediaFile(filename string) bool {
+	mediaType := getMediaType(filename)
+	return strings.HasPrefix(mediaType, "image/") || strings.HasPrefix(mediaType, "video/")
+}
+
+// localMediaFiles finds the images and videos under dir and calculates their
+// MD5 hashes.  Hidden files and folders are skipped.
+func localMediaFiles(dir string) ([]localFile, error) {
+	files := make([]localFile, 0, 100)
+
+	err := filepath.Walk(dir, func(fname string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		hidden := strings.HasPrefix(info.Name(), ".") && fname != dir
+		if info.IsDir() {
+			if hidden {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if hidden || !info.Mode().IsRegular() || !isMediaFile(fname) {
+			return nil
+		}
+
+		hash, _, err := calcMD5(fname)
+		if err != nil {
+			return err
+		}
+		files = append(files, localFile{fname, hash})
+		return nil
+	})
+
+	return files, err
+}
+
+// compareFiles splits local files and album images into those only found
+// locally and those only found in the album, based on their MD5 hashes.  Only
+// the first local file with a given hash is reported.
+func compareFiles(files []localFile, images []albumImageJSON) ([]string, []albumImageJSON) {
+	remoteHashes := make(map[string]bool, len(images))
+	for _, img := range images {
+		remoteHashes[img.ArchivedMD5] = true
+	}
+
+	localHashes := make(map[string]bool, len(files))
+	localOnly := make([]string, 0, len(files))
+	for _, f := range files {
+		if !remoteHashes[f.Hash] && !localHashes[f.Hash] {
+			localOnly = append(localOnly, f.Path)
+		}
+		localHashes[f.Hash] = true
+	}
+
+	remoteOnly := make([]albumImageJSON, 0, len(images))
+	for _, img := range images {
+		if !localHashes[img.ArchivedMD5] {
+			remoteOnly = append(remoteOnly, img)
+		}
+	}
+
+	return localOnly, remoteOnly
+}
+
+// syncAlbum uploads the files in localDir that are missing from the album and
+// reports the album's images that are missing from localDir.  Images only in
+// the album may optionally be downloaded to localDir or deleted from the album.
+func syncAlbum(localDir string, albumKey string, download bool, deleteRemote bool) {
+	if download && deleteRemote {
+		log.Println("Error, cannot both download and delete remote only images!")
+		return
+	}
+
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	fmt.Println("Hashing files in " + localDir)
+	files, err := localMediaFiles(localDir)
+	if err != nil {
+		log.Println("Error reading local files: " + err.Error())
+		return
+	}
+
+	var client = http.Client{}
+	db := openDB()
+	defer db.Close()
+
+	images, err := refreshAlbumImages(&client, userToken, db, albumKey, imageDownloadFilter+",DateTimeUploaded")
+	if err != nil {
+		log.Println("Error getting album images: " + err.Error())
+		return
+	}
+
+	localOnly, remoteOnly := compareFiles(files, images)
+	fmt.Printf("%d local files, %d album images, %d to upload, %d only in album.\n",
+		len(files), len(images), len(localOnly), len(remoteOnly))
+
+	if len(localOnly) > 0 {
+		uploadFiles(parallelFlag, userToken, false, albumKey, localOnly)
+	}
+
+	if len(remoteOnly) == 0 {
+		return
+	}
+
+	fmt.Println("\nOnly in album:")
+	for _, img := range remoteOnly {
+		fmt.Println(img.FileName + " :: " + img.ImageKey)
+	}
+
+	if download {
+		failed := downloadImages(&client, userToken, remoteOnly, localDir, parallelFlag, false, nil)
+		if failed > 0 {
+			fmt.Printf("%d images not downloaded.\n", failed)
+		}
+	} else if deleteRemote {
+		if !yesFlag && !confirm(fmt.Sprintf("Delete %d images from album %s?", len(remoteOnly), albumKey)) {
+			return
+		}
+		deleteAlbumImages(&client, userToken, db, albumKey, remoteOnly)
+	}
+}
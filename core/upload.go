@@ -15,6 +15,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/md5"
 	"database/sql"
 	"encoding/json"
@@ -26,8 +27,10 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gomodule/oauth1/oauth"
@@ -35,9 +38,17 @@ import (
 
 const uploadURI = "https://upload.smugmug.com/"
 
+// Image created by an upload.
+type uploadImageJSON struct {
+	ImageURI      string
+	AlbumImageURI string
+	URL           string
+}
+
 type uploadResponseJSON struct {
 	Stat    string
 	Message string
+	Image   uploadImageJSON
 }
 
 // Returns true if image's MD5 hash already exists in the given album.  If there
@@ -52,7 +63,9 @@ func isDuplicateImage(db *sql.DB, albumKey string, hash string) (bool, []string)
 }
 
 // upload transfers a single file to the SmugMug album identifed by key.
-func upload(allowDupes bool, albumKey string, filename string) {
+// Unless duplicates are allowed, the album's images are refreshed first if
+// they were fetched longer than cacheTTL ago.
+func upload(allowDupes bool, albumKey string, filename string, cacheTTL time.Duration) {
 	userToken, err := loadUserToken()
 	if err != nil {
 		log.Println("Error reading OAuth token: " + err.Error())
@@ -63,6 +76,10 @@ func upload(allowDupes bool, albumKey string, filename string) {
 	db := openDB()
 	defer db.Close()
 
+	if !allowDupes {
+		refreshStaleImages(&client, userToken, db, albumKey, cacheTTL)
+	}
+
 	err = postImage(&client, uploadURI, userToken, db, allowDupes, albumKey, filename, retriesFlag+1)
 	if err != nil {
 		log.Println("Error uploading: " + err.Error())
@@ -88,8 +105,85 @@ func expandFileNames(
 	return expanded
 }
 
-// multiUpload uploads files in parallel to the given SmugMug album.
-func multiUpload(numParallel int, allowDupes bool, albumKey string, filenames []string) {
+// existingFiles returns the files that exist, reporting the ones that don't.
+func existingFiles(filenames []string) []string {
+	existing := make([]string, 0, len(filenames))
+	for _, fname := range filenames {
+		if _, err := os.Stat(fname); err != nil {
+			log.Println("Skipping " + fname + ": " + err.Error())
+			continue
+		}
+		existing = append(existing, fname)
+	}
+	return existing
+}
+
+// uniqueFileNames removes repeated filenames while preserving the order in
+// which they first appear.
+func uniqueFileNames(filenames []string) []string {
+	seen := make(map[string]bool, len(filenames))
+	unique := make([]string, 0, len(filenames))
+
+	for _, fname := range filenames {
+		if seen[fname] {
+			continue
+		}
+		seen[fname] = true
+		unique = append(unique, fname)
+	}
+
+	return unique
+}
+
+// readManifest reads a list of filenames from the given file.  A filename of
+// "-" reads from stdin.
+func readManifest(filename string) ([]string, error) {
+	if filename == "-" {
+		return parseManifest(os.Stdin)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+	return parseManifest(file)
+}
+
+// parseManifest splits the contents of a manifest into filenames.  Entries are
+// NUL delimited if the manifest contains a NUL (as produced by find -print0),
+// otherwise they are newline delimited.  Empty entries are dropped.
+func parseManifest(r io.Reader) ([]string, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := []byte("\n")
+	if bytes.IndexByte(contents, 0) >= 0 {
+		sep = []byte{0}
+	}
+
+	filenames := make([]string, 0, 20)
+	for _, entry := range bytes.Split(contents, sep) {
+		fname := strings.TrimSuffix(string(entry), "\r")
+		if fname == "" {
+			continue
+		}
+		filenames = append(filenames, fname)
+	}
+
+	return filenames, nil
+}
+
+// multiUpload uploads files in parallel to the given SmugMug album.  The
+// filenames may be patterns, while the manifest holds exact paths read by
+// readManifest.  Unless duplicates are allowed, the album's images are
+// refreshed first if they were fetched longer than cacheTTL ago.
+func multiUpload(numParallel int, allowDupes bool, albumKey string, filenames []string,
+	manifest []string, cacheTTL time.Duration) {
+
 	if numParallel < 1 {
 		log.Println("Error, must upload at least 1 file at a time!")
 		return
@@ -101,14 +195,30 @@ func multiUpload(numParallel int, allowDupes bool, albumKey string, filenames []
 		return
 	}
 
+	if !allowDupes {
+		var client = http.Client{}
+		db := openDB()
+		refreshStaleImages(&client, userToken, db, albumKey, cacheTTL)
+		db.Close()
+	}
+
 	expFileNames := expandFileNames(filenames, filepath.Glob)
+	expFileNames = uniqueFileNames(append(expFileNames, existingFiles(manifest)...))
 	fmt.Println(expFileNames)
+	uploadFiles(numParallel, userToken, allowDupes, albumKey, expFileNames)
+}
+
+// uploadFiles uploads the given files, numParallel at a time, without any
+// pattern matching of the filenames.
+func uploadFiles(numParallel int, userToken *oauth.Credentials, allowDupes bool,
+	albumKey string, filenames []string) {
+
 	var client = http.Client{}
 	db := openDB()
 	defer db.Close()
 
 	semaph := make(chan int, numParallel)
-	for _, filename := range expFileNames {
+	for _, filename := range filenames {
 		semaph <- 1
 		go func(filename string) {
 			fmt.Println("go " + filename)
@@ -128,6 +238,19 @@ func multiUpload(numParallel int, allowDupes bool, albumKey string, filenames []
 	}
 }
 
+// imageKeyFromURI returns the key of the image at a URI such as
+// /api/v2/image/AbC123-0, where the number after the dash is the version.
+func imageKeyFromURI(uri string) string {
+	key := path.Base(uri)
+	if i := strings.LastIndex(key, "-"); i > 0 {
+		key = key[:i]
+	}
+	if key == "." || key == "/" {
+		return ""
+	}
+	return key
+}
+
 // getMediaType determines the value for the Content-Type header field based
 // on the file extension.
 func getMediaType(filename string) string {
@@ -155,16 +278,45 @@ func calcMD5(imgFileName string) (string, int64, error) {
 	return fmt.Sprintf("%x", md5Sum), size, nil
 }
 
-// postImage uploads a single image to SmugMug via the POST method.
+// postImage uploads a single image to SmugMug via the POST method and
+// records the attempt in the upload history.
 // uri is the protocol + hostname of the server
 func postImage(client *http.Client, uri string, credentials *oauth.Credentials,
 	db *sql.DB, allowDupes bool,
 	albumKey string, imgFileName string, tries uint) error {
 
+	start := time.Now()
+	attempt := uploadAttempt{
+		Started:  start.UTC().Format(time.RFC3339),
+		Path:     imgFileName,
+		AlbumKey: albumKey,
+	}
+	if absPath, err := filepath.Abs(imgFileName); err == nil {
+		attempt.Path = absPath
+	}
+
+	err := sendImage(client, uri, credentials, db, allowDupes, albumKey, imgFileName, tries, &attempt)
+	if err != nil {
+		attempt.Result = uploadResultFailed
+		attempt.Error = err.Error()
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	writeUploadAttempt(db, attempt)
+
+	return err
+}
+
+// sendImage does the work of postImage, filling in the attempt's size,
+// number of tries and result.
+func sendImage(client *http.Client, uri string, credentials *oauth.Credentials,
+	db *sql.DB, allowDupes bool,
+	albumKey string, imgFileName string, tries uint, attempt *uploadAttempt) error {
+
 	md5Str, imgSize, err := calcMD5(imgFileName)
 	if err != nil {
 		return err
 	}
+	attempt.Size = imgSize
 
 	if !allowDupes {
 		isDupe, filenames := isDuplicateImage(db, albumKey, md5Str)
@@ -173,13 +325,16 @@ func postImage(client *http.Client, uri string, credentials *oauth.Credentials,
 			for _, f := range filenames {
 				fmt.Printf("\t%s\n", f)
 			}
+			attempt.Result = uploadResultDuplicate
 			return nil
 		}
 	}
 
 	var success = false
+	var respJSON uploadResponseJSON
 	var tryCount uint
 	for tryCount = 0; tryCount < tries; tryCount++ {
+		attempt.Attempts = int(tryCount) + 1
 
 		file, err := os.Open(imgFileName)
 		if err != nil {
@@ -241,7 +396,6 @@ func postImage(client *http.Client, uri string, credentials *oauth.Credentials,
 		fmt.Println(resp.Status)
 		fmt.Println(string(bytes))
 
-		var respJSON uploadResponseJSON
 		err = json.Unmarshal(bytes, &respJSON)
 		if err != nil {
 			log.Println("Error decoding upload response JSON: " + err.Error())
@@ -258,8 +412,19 @@ func postImage(client *http.Client, uri string, credentials *oauth.Credentials,
 	}
 
 	if success {
-		imgData := []imageJSON{{md5Str, imgFileName}}
-		writeImageData(db, albumKey, imgData)
+		attempt.Result = uploadResultUploaded
+		attempt.ImageURI = respJSON.Image.ImageURI
+		imageKey := imageKeyFromURI(respJSON.Image.ImageURI)
+		// The upload response has no upload time, so the row's upload time
+		// stays empty until the album's images are next refreshed.
+		writeAlbumImages(db, albumKey, []albumImageJSON{
+			{ImageKey: imageKey, ArchivedMD5: md5Str, FileName: filepath.Base(imgFileName)}})
+		writeUpload(db, albumKey, uploadRecord{
+			ImageKey: imageKey,
+			Hash:     md5Str,
+			FileName: imgFileName,
+			Uploaded: time.Now().Format(time.RFC3339),
+		})
 		return nil
 	}
 
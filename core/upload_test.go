@@ -335,7 +335,7 @@ func TestDoesNotUploadDuplicateImage(t *testing.T) {
 
 	hash, _, err := calcMD5(filename)
 	imgData := []imageJSON{{hash, filename}}
-	writeImageData(db, albumKey, imgData)
+	writeTestImageData(db, albumKey, imgData)
 
 	allowDupes := false
 	nTries := uint(1)
@@ -350,3 +350,18 @@ func TestDoesNotUploadDuplicateImage(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestImageKeyFromURI(t *testing.T) {
+	tests := map[string]string{
+		"/api/v2/image/AbC123-0": "AbC123",
+		"/api/v2/image/AbC123-2": "AbC123",
+		"":                       "",
+	}
+
+	for uri, expected := range tests {
+		actual := imageKeyFromURI(uri)
+		if expected != actual {
+			t.Errorf("uri: %s, expected: %s, actual: %s", uri, expected, actual)
+		}
+	}
+}
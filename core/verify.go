@@ -0,0 +1,182 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+// hashMismatch is an image whose row in the image table has a different hash
+// than the image in the album, such as when the image was replaced.
+type hashMismatch struct {
+	Row   imageJSON
+	Image albumImageJSON
+}
+
+// imageTableDiff lists how an album's rows in the image table differ from the
+// album's images on SmugMug.
+type imageTableDiff struct {
+	Stale      []imageJSON      // Rows for images no longer in the album.
+	Missing    []albumImageJSON // Images in the album with no row.
+	Mismatched []hashMismatch
+}
+
+func (d imageTableDiff) empty() bool {
+	return len(d.Stale) == 0 && len(d.Missing) == 0 && len(d.Mismatched) == 0
+}
+
+// diffImageTable compares an album's rows with its images.  Rows and images
+// match by hash, since that's what duplicate checks use.  A leftover row and
+// image with the same filename are a hash mismatch.  Rows written for uploads
+// hold the local path, so only the base of the filename is compared.
+func diffImageTable(rows []imageJSON, images []albumImageJSON) imageTableDiff {
+	imagesByHash := make(map[string][]int, len(images))
+	for i, img := range images {
+		imagesByHash[img.ArchivedMD5] = append(imagesByHash[img.ArchivedMD5], i)
+	}
+
+	matched := make([]bool, len(images))
+	unmatchedRows := make([]imageJSON, 0)
+	for _, row := range rows {
+		indexes := imagesByHash[row.ArchivedMD5]
+		if len(indexes) == 0 {
+			unmatchedRows = append(unmatchedRows, row)
+			continue
+		}
+		matched[indexes[0]] = true
+		imagesByHash[row.ArchivedMD5] = indexes[1:]
+	}
+
+	unmatchedByName := make(map[string][]int)
+	for i, img := range images {
+		if !matched[i] {
+			unmatchedByName[img.FileName] = append(unmatchedByName[img.FileName], i)
+		}
+	}
+
+	var diff imageTableDiff
+	for _, row := range unmatchedRows {
+		name := filepath.Base(filepath.FromSlash(row.FileName))
+		if indexes := unmatchedByName[name]; len(indexes) > 0 {
+			diff.Mismatched = append(diff.Mismatched, hashMismatch{row, images[indexes[0]]})
+			matched[indexes[0]] = true
+			unmatchedByName[name] = indexes[1:]
+			continue
+		}
+		diff.Stale = append(diff.Stale, row)
+	}
+
+	for i, img := range images {
+		if !matched[i] {
+			diff.Missing = append(diff.Missing, img)
+		}
+	}
+
+	return diff
+}
+
+// fixImageTable makes an album's rows match its images.
+func fixImageTable(db *sql.DB, albumKey string, diff imageTableDiff) {
+	for _, row := range diff.Stale {
+		removeImageData(db, albumKey, albumImageJSON{ArchivedMD5: row.ArchivedMD5, FileName: row.FileName})
+	}
+
+	added := append([]albumImageJSON{}, diff.Missing...)
+	for _, mismatch := range diff.Mismatched {
+		removeImageData(db, albumKey, albumImageJSON{ArchivedMD5: mismatch.Row.ArchivedMD5,
+			FileName: mismatch.Row.FileName})
+		added = append(added, mismatch.Image)
+	}
+	writeAlbumImages(db, albumKey, added)
+}
+
+// printImageTableDiff lists the differences found for an album.
+func printImageTableDiff(albumKey string, rowCount int, imageCount int, diff imageTableDiff) {
+	fmt.Printf("%s: %d rows, %d images, %d stale, %d missing, %d mismatched\n", albumKey,
+		rowCount, imageCount, len(diff.Stale), len(diff.Missing), len(diff.Mismatched))
+	for _, row := range diff.Stale {
+		fmt.Println("\tstale: " + row.FileName + " " + row.ArchivedMD5)
+	}
+	for _, img := range diff.Missing {
+		fmt.Println("\tmissing: " + img.FileName + " " + img.ArchivedMD5)
+	}
+	for _, mismatch := range diff.Mismatched {
+		fmt.Println("\tmismatch: " + mismatch.Image.FileName + " " +
+			mismatch.Row.ArchivedMD5 + " -> " + mismatch.Image.ArchivedMD5)
+	}
+}
+
+// verifyAlbum compares an album's rows with its images, fixing the rows if
+// fix is true.  An album that no longer exists has only stale rows.  Returns
+// false if the album couldn't be checked or differs.
+func verifyAlbum(client *http.Client, userToken *oauth.Credentials, db *sql.DB,
+	albumKey string, fix bool) bool {
+
+	images, err := fetchAlbumImages(client, userToken, albumKey, imageSyncFilter)
+	if err != nil && !isNotFound(err) {
+		log.Println("Error getting images for album " + albumKey + ": " + err.Error())
+		return false
+	}
+
+	rows := getAlbumImageData(db, albumKey)
+	diff := diffImageTable(rows, images)
+	printImageTableDiff(albumKey, len(rows), len(images), diff)
+	if diff.empty() {
+		return true
+	}
+
+	if fix {
+		fixImageTable(db, albumKey, diff)
+		fmt.Println("\tfixed")
+	}
+	return false
+}
+
+// verify checks the image table against SmugMug for one album, or for every
+// album in the table if albumKey is empty.
+func verify(albumKey string, fix bool) {
+	userToken, err := loadUserToken()
+	if err != nil {
+		log.Println("Error reading OAuth token: " + err.Error())
+		return
+	}
+
+	db := openDB()
+	defer db.Close()
+
+	albumKeys := []string{albumKey}
+	if albumKey == "" {
+		albumKeys = getImageAlbumKeys(db)
+	}
+
+	var client = http.Client{}
+	differ := 0
+	for _, key := range albumKeys {
+		if !verifyAlbum(&client, userToken, db, key, fix) {
+			differ++
+		}
+	}
+
+	fmt.Printf("\nChecked %d albums, %d differ or couldn't be checked.\n", len(albumKeys), differ)
+	if differ > 0 && !fix {
+		fmt.Println("Use -fix to update smuggo's database.")
+	}
+}
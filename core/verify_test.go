@@ -0,0 +1,107 @@
+// Copyright 2026 Timothy Gion
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gomodule/oauth1/oauth"
+)
+
+var verifyTestRows = []imageJSON{
+	{"hash-1", "/home/me/milk.jpg"},
+	{"hash-2", "rose.jpg"},
+	{"hash-old", "orange.png"},
+	{"hash-gone", "gone.jpg"},
+}
+
+var verifyTestImages = []albumImageJSON{
+	{ImageKey: "key-1", FileName: "milk.jpg", ArchivedMD5: "hash-1"},
+	{ImageKey: "key-2", FileName: "rose-renamed.jpg", ArchivedMD5: "hash-2"},
+	{ImageKey: "key-4", FileName: "orange.png", ArchivedMD5: "hash-new"},
+	{ImageKey: "key-3", FileName: "new.jpg", ArchivedMD5: "hash-3"},
+}
+
+func TestDiffImageTable(t *testing.T) {
+	diff := diffImageTable(verifyTestRows, verifyTestImages)
+
+	if !reflect.DeepEqual([]imageJSON{{"hash-gone", "gone.jpg"}}, diff.Stale) {
+		t.Errorf("expected stale: gone.jpg, actual: %v", diff.Stale)
+	}
+	if !reflect.DeepEqual(verifyTestImages[3:], diff.Missing) {
+		t.Errorf("expected missing: new.jpg, actual: %v", diff.Missing)
+	}
+
+	expMismatch := []hashMismatch{{verifyTestRows[2], verifyTestImages[2]}}
+	if !reflect.DeepEqual(expMismatch, diff.Mismatched) {
+		t.Errorf("expected mismatch: orange.png, actual: %v", diff.Mismatched)
+	}
+
+	if !diffImageTable(verifyTestRows[:2], verifyTestImages[:2]).empty() {
+		t.Error("Expected no differences")
+	}
+}
+
+func TestFixImageTable(t *testing.T) {
+	db := setUpTestDB(t)
+	defer db.Close()
+
+	createTables(db, imageTableVersion)
+	writeTestImageData(db, "fake-album-key", verifyTestRows)
+
+	fixImageTable(db, "fake-album-key", diffImageTable(verifyTestRows, verifyTestImages))
+
+	hashes := make([]string, 0, 4)
+	for _, row := range getAlbumImageData(db, "fake-album-key") {
+		hashes = append(hashes, row.ArchivedMD5)
+	}
+	sort.Strings(hashes)
+
+	expected := []string{"hash-1", "hash-2", "hash-3", "hash-new"}
+	if !reflect.DeepEqual(expected, hashes) {
+		t.Errorf("expected: %s, actual: %s", expected, hashes)
+	}
+
+	keys := make([]string, 0, 4)
+	for _, row := range getImageRows(db, "fake-album-key") {
+		keys = append(keys, row.ImageKey)
+	}
+	sort.Strings(keys)
+
+	expKeys := []string{"", "", "key-3", "key-4"}
+	if !reflect.DeepEqual(expKeys, keys) {
+		t.Errorf("expected: %s, actual: %s", expKeys, keys)
+	}
+
+	rows := getAlbumImageData(db, "fake-album-key")
+	if diff := diffImageTable(rows, verifyTestImages); !diff.empty() {
+		t.Errorf("Expected no differences after fix, found %v", diff)
+	}
+}
+
+func TestIsNotFoundWrapped(t *testing.T) {
+	var client = http.Client{}
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, err := getAlbumImagesPage(&client, &oauth.Credentials{}, server.URL, imageHashFilter, 1, 10)
+	if !isNotFound(err) {
+		t.Errorf("Expected not found error, actual: %v", err)
+	}
+}